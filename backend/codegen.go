@@ -1 +1,132 @@
 package backend
+
+import (
+	"bytes"
+	"compiler_project/diagnostics"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// maxToolOutputLines — сколько строк вывода инструмента попадает в примечания диагностики
+const maxToolOutputLines = 20
+
+// Config — параметры сборки машинного кода из LLVM IR
+type Config struct {
+	OptLevel int    // уровень оптимизации 0..3, как у -O0..-O3
+	Object   bool   // только объектный файл, без компоновки (-c)
+	Target   string // триплет платформы; пустая строка — платформа по умолчанию инструмента
+	Output   string // путь к исполняемому или объектному файлу
+}
+
+// Toolchain — найденные в PATH внешние инструменты. Если есть clang, он делает
+// всё сам; иначе llc переводит IR в объектный файл, а cc его компонует.
+type Toolchain struct {
+	Clang string
+	LLC   string
+	CC    string
+}
+
+// FindToolchain ищет clang, а без него — пару llc и cc (или gcc).
+// false означает, что собрать программу нечем.
+func FindToolchain() (Toolchain, bool) {
+	var tc Toolchain
+	if path, err := exec.LookPath("clang"); err == nil {
+		tc.Clang = path
+		return tc, true
+	}
+	if path, err := exec.LookPath("llc"); err == nil {
+		tc.LLC = path
+	}
+	for _, name := range []string{"cc", "gcc"} {
+		if path, err := exec.LookPath(name); err == nil {
+			tc.CC = path
+			break
+		}
+	}
+	return tc, tc.LLC != "" && tc.CC != ""
+}
+
+// String описывает инструменты для сообщений драйвера
+func (tc Toolchain) String() string {
+	if tc.Clang != "" {
+		return "clang"
+	}
+	return "llc + " + filepath.Base(tc.CC)
+}
+
+// Build собирает модуль ir в cfg.Output. IR и промежуточный объектный файл
+// пишутся во временный каталог, который удаляется после сборки.
+// Ошибка инструмента возвращается диагностикой B0001 с его выводом в примечаниях.
+func (tc Toolchain) Build(ir string, cfg Config) error {
+	if cfg.OptLevel < 0 || cfg.OptLevel > 3 {
+		return diagnostics.Errorf(diagnostics.NoSpan, diagnostics.CodeToolchainFailed,
+			"неверный уровень оптимизации -O%d, допустимы 0..3", cfg.OptLevel)
+	}
+	dir, err := os.MkdirTemp("", "compiler-build-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	module := filepath.Join(dir, "module.ll")
+	if err := os.WriteFile(module, []byte(ir), 0o644); err != nil {
+		return err
+	}
+	opt := fmt.Sprintf("-O%d", cfg.OptLevel)
+
+	if tc.Clang != "" {
+		// IR уже содержит триплет, поэтому предупреждение о его замене не нужно
+		args := []string{opt, "-Wno-override-module"}
+		if cfg.Target != "" {
+			args = append(args, "--target="+cfg.Target)
+		}
+		if cfg.Object {
+			args = append(args, "-c")
+		}
+		return runTool(tc.Clang, append(args, "-o", cfg.Output, module)...)
+	}
+
+	object := cfg.Output
+	if !cfg.Object {
+		object = filepath.Join(dir, "module.o")
+	}
+	// PIC нужен, потому что cc по умолчанию компонует позиционно-независимый исполняемый файл
+	args := []string{opt, "-filetype=obj", "-relocation-model=pic"}
+	if cfg.Target != "" {
+		args = append(args, "-mtriple="+cfg.Target)
+	}
+	if err := runTool(tc.LLC, append(args, "-o", object, module)...); err != nil {
+		return err
+	}
+	if cfg.Object {
+		return nil
+	}
+	return runTool(tc.CC, "-o", cfg.Output, object)
+}
+
+// runTool запускает инструмент и превращает неудачу в диагностику
+func runTool(path string, args ...string) error {
+	var output bytes.Buffer
+	cmd := exec.Command(path, args...)
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Run(); err != nil {
+		d := diagnostics.Errorf(diagnostics.NoSpan, diagnostics.CodeToolchainFailed,
+			"%s завершился с ошибкой: %v", filepath.Base(path), err)
+		lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+		for i, line := range lines {
+			if i == maxToolOutputLines {
+				d = d.WithNote("… ещё строк: %d", len(lines)-i)
+				break
+			}
+			if line != "" {
+				d = d.WithNote("%s", line)
+			}
+		}
+		return d
+	}
+	return nil
+}
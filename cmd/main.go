@@ -1,61 +1,407 @@
 package main
 
 import (
+	"bytes"
+	"compiler_project/backend"
+	"compiler_project/diagnostics"
+	"compiler_project/interp"
 	"compiler_project/lexer"
 	"compiler_project/llvmgen"
 	"compiler_project/parser"
+	"compiler_project/parser/ast"
 	"compiler_project/semantics"
 	"compiler_project/tac"
+	"flag"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
 )
 
+// Коды завершения драйвера
+const (
+	exitOK            = 0
+	exitLexicalError  = 1
+	exitSyntaxError   = 2
+	exitSemanticError = 3
+	exitRuntimeError  = 4
+	exitIOError       = 5
+	exitCodegenError  = 6
+	exitBuildError    = 7
+	exitUsageError    = 64
+)
+
+// Стадии, результат которых можно вывести через --emit
+const (
+	emitTokens = "tokens"
+	emitAST    = "ast"
+	emitTAC    = "tac"
+	emitLLVM   = "llvm"
+	emitRun    = "run"
+	emitBuild  = "build"
+)
+
+// options — настройки стадий, заданные флагами
+type options struct {
+	emit     string
+	maxDepth int
+	llvm     llvmgen.Options
+	build    backend.Config
+}
+
+// optLevelFlag — уровень оптимизации в привычной записи -O2
+var optLevelFlag = regexp.MustCompile(`^-O(\d+)$`)
+
+// source — один входной файл (или stdin)
+type source struct {
+	name string
+	text string
+}
+
 func main() {
-	scope := map[string]interface{}{}
-	checker := semantics.NewTypeChecker()
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("compiler", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	output := flags.String("o", "", "файл для результата (по умолчанию stdout; в режиме build — имя первого файла без расширения)")
+	emit := flags.String("emit", emitLLVM, "что вывести: tokens|ast|tac|llvm|run|build")
+	maxDepth := flags.Int("max-depth", interp.DefaultMaxCallDepth, "предел вложенности вызовов в режиме run (0 — без ограничения)")
+	waitKey := flags.Bool("wait-key", false, "перед выходом из скомпилированной программы ждать нажатия Enter")
+	target := flags.String("target", llvmgen.DefaultTarget, "целевая платформа: "+strings.Join(llvmgen.Targets(), "|"))
+	optLevel := flags.Int("O", 0, "уровень оптимизации в режиме build: -O0..-O3")
+	object := flags.Bool("c", false, "в режиме build собрать объектный файл без компоновки")
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "использование: compiler [--emit=tokens|ast|tac|llvm|run|build] [--target=триплет] [-O0..-O3] [-c] [-o файл] [файл ...]\n")
+		fmt.Fprintf(stderr, "без файлов (или с \"-\") исходный код читается из stdin\n\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(normalizeArgs(args)); err != nil {
+		return exitUsageError
+	}
+
+	switch *emit {
+	case emitTokens, emitAST, emitTAC, emitLLVM, emitRun, emitBuild:
+	default:
+		fmt.Fprintf(stderr, "неизвестный режим --emit=%s\n", *emit)
+		flags.Usage()
+		return exitUsageError
+	}
+	if _, ok := llvmgen.LookupTarget(*target); !ok {
+		fmt.Fprintf(stderr, "неизвестная платформа --target=%s; поддерживаются: %s\n", *target, strings.Join(llvmgen.Targets(), ", "))
+		return exitUsageError
+	}
+	if *optLevel < 0 || *optLevel > 3 {
+		fmt.Fprintf(stderr, "неверный уровень оптимизации -O%d, допустимы -O0..-O3\n", *optLevel)
+		return exitUsageError
+	}
+	if *emit != emitBuild && (*object || flagSet(flags, "O")) {
+		fmt.Fprintf(stderr, "флаги -c и -O действуют только в режиме --emit=build\n")
+		return exitUsageError
+	}
+	if *emit == emitRun && *output != "" {
+		fmt.Fprintf(stderr, "флаг -o не действует в режиме --emit=run: программа печатает в stdout\n")
+		return exitUsageError
+	}
+
+	sources, err := readSources(flags.Args(), stdin)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitIOError
+	}
+
+	// Результат для -o копится в памяти и записывается только после успешной
+	// компиляции, чтобы ошибка не затёрла прежнее содержимое файла
+	var out io.Writer = stdout
+	var buffered *bytes.Buffer
+	if *output != "" && *emit != emitBuild {
+		buffered = &bytes.Buffer{}
+		out = buffered
+	}
+
+	opts := options{
+		emit:     *emit,
+		maxDepth: *maxDepth,
+		llvm: llvmgen.Options{
+			WaitForKey:     *waitKey,
+			Target:         *target,
+			SourceFilename: sources[0].name,
+		},
+		build: backend.Config{
+			OptLevel: *optLevel,
+			Object:   *object,
+			Target:   *target,
+			Output:   buildOutput(*output, sources[0].name, *object),
+		},
+	}
+	// Результат не должен затирать исходник: prog без расширения собирается в prog
+	for _, path := range outputPaths(*output, opts) {
+		if src, ok := inputFile(path, sources); ok {
+			fmt.Fprintf(stderr, "результат %s совпадает с входным файлом %s; укажите другой файл через -o\n", path, src)
+			return exitUsageError
+		}
+	}
+
+	code := compile(sources, opts, out, stderr)
+	if buffered != nil && code == exitOK {
+		if err := os.WriteFile(*output, buffered.Bytes(), 0o666); err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitIOError
+		}
+	}
+	return code
+}
+
+// normalizeArgs переписывает -O2 в -O=2, чтобы флаг понял пакет flag
+func normalizeArgs(args []string) []string {
+	normalized := make([]string, len(args))
+	for i, arg := range args {
+		if arg == "--" {
+			copy(normalized[i:], args[i:])
+			break
+		}
+		normalized[i] = optLevelFlag.ReplaceAllString(arg, "-O=$1")
+	}
+	return normalized
+}
 
-	text := `int a=1;
-while a less 10{
-	show a;
-	int a = a + 1;
-	};`
+// flagSet сообщает, что флаг name задан в командной строке явно
+func flagSet(flags *flag.FlagSet, name string) bool {
+	set := false
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
 
-	l := lexer.NewLexer(text)
-	l.LexerAnalysis()
-	fmt.Println(l.Tokens)
+// buildOutput выбирает имя результата режима build: prog.src собирается в prog
+// (или prog.o с -c), программа из stdin — в a.out (или a.o)
+func buildOutput(output, sourceName string, object bool) string {
+	if output != "" {
+		return output
+	}
+	base := "a"
+	if sourceName != "<stdin>" {
+		base = strings.TrimSuffix(filepath.Base(sourceName), filepath.Ext(sourceName))
+	}
+	switch {
+	case object:
+		return base + ".o"
+	case base == "a":
+		return "a.out"
+	default:
+		return base
+	}
+}
 
-	p := parser.NewParser(l.Tokens)
-	p.Scope = scope
-	rootNode := p.ParseCode()
+// outputPaths — файлы, которые может записать драйвер в выбранном режиме
+func outputPaths(output string, opts options) []string {
+	switch opts.emit {
+	case emitBuild:
+		return []string{opts.build.Output, fallbackIRPath(opts.build.Output)}
+	}
+	if output == "" {
+		return nil
+	}
+	return []string{output}
+}
 
-	_, err := checker.Check(rootNode)
+// inputFile находит входной файл, на который указывает path (в том числе
+// через другой относительный путь или ссылку)
+func inputFile(path string, sources []source) (string, bool) {
+	info, err := os.Stat(path)
 	if err != nil {
-		fmt.Printf("Ошибка семантики: %v\n", err)
-		return
+		return "", false
+	}
+	for _, src := range sources {
+		if src.name == "<stdin>" {
+			continue
+		}
+		if srcInfo, err := os.Stat(src.name); err == nil && os.SameFile(info, srcInfo) {
+			return src.name, true
+		}
+	}
+	return "", false
+}
+
+// fallbackIRPath — куда режим build пишет IR, если собрать программу нечем
+func fallbackIRPath(output string) string {
+	return strings.TrimSuffix(output, filepath.Ext(output)) + ".ll"
+}
+
+func readSources(paths []string, stdin io.Reader) ([]source, error) {
+	if len(paths) == 0 {
+		paths = []string{"-"}
+	}
+
+	var sources []source
+	for _, path := range paths {
+		var data []byte
+		var err error
+		if path == "-" {
+			data, err = io.ReadAll(stdin)
+			path = "<stdin>"
+		} else {
+			data, err = os.ReadFile(path)
+		}
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, source{name: path, text: string(data)})
+	}
+	return sources, nil
+}
+
+// compile прогоняет стадии по порядку, выводит результат стадии opts.emit
+// и возвращает код завершения. Диагностики всех стадий печатаются в stderr.
+func compile(sources []source, opts options, out, stderr io.Writer) int {
+	files := diagnostics.NewFileSet()
+	report := func(diags diagnostics.List) bool {
+		for _, d := range diags {
+			files.Render(stderr, d)
+		}
+		return diags.HasErrors()
+	}
+
+	program := &ast.StatementsNode{}
+	lexFailed, parseFailed := false, false
+	for _, src := range sources {
+		file := files.AddFile(src.name, src.text)
+
+		l := lexer.NewLexer(src.text)
+		tokens := *l.LexerAnalysis()
+		// Переводим позиции в общее адресное пространство FileSet
+		for i := range tokens {
+			tokens[i].Pos += file.Base
+		}
+		l.Diagnostics.Shift(file.Base)
+		if report(l.Diagnostics) {
+			lexFailed = true
+			continue
+		}
+		if opts.emit == emitTokens {
+			for _, tok := range tokens {
+				line, col := file.Position(tok.Pos - file.Base)
+				fmt.Fprintf(out, "%s:%d:%d\t%s\t%q\n", src.name, line, col, tok.TypeToken.Name, tok.Text)
+			}
+			continue
+		}
+
+		// Даже при синтаксических ошибках парсер возвращает частичное дерево
+		p := parser.NewParser(tokens)
+		root := p.ParseCode()
+		if report(p.Diagnostics) {
+			parseFailed = true
+		}
+		program.CodeStrings = append(program.CodeStrings, root.CodeStrings...)
+	}
+
+	switch {
+	case lexFailed:
+		return exitLexicalError
+	case opts.emit == emitTokens:
+		return exitOK
+	case opts.emit == emitAST:
+		if code := writeOutput(out, stderr, ast.Dump(program)); code != exitOK {
+			return code
+		}
+		if parseFailed {
+			return exitSyntaxError
+		}
+		return exitOK
+	case parseFailed:
+		return exitSyntaxError
+	}
+
+	checker := semantics.NewTypeChecker()
+	if _, err := checker.Check(program); err != nil {
+		d, ok := err.(diagnostics.Diagnostic)
+		if !ok {
+			d = diagnostics.Errorf(diagnostics.NoSpan, "", "%v", err)
+		}
+		report(diagnostics.List{d})
+		return exitSemanticError
+	}
+
+	if opts.emit == emitRun {
+		return interpret(program, out, opts.maxDepth, report)
 	}
-	p.Run(rootNode)
-	scope = p.Scope
 
-	fmt.Println("=== Трёхадресный код ===")
 	builder := tac.NewTACBuilder()
-	builder.Generate(rootNode)
+	builder.SetTypes(checker.Types)
+	builder.Generate(program)
+	if report(builder.Diagnostics) {
+		return exitCodegenError
+	}
 	builder.Optimize()
-	builder.Print()
+	if opts.emit == emitTAC {
+		builder.Fprint(out)
+		return exitOK
+	}
 
-	llvm := llvmgen.NewLLVMBuilder()
+	llvm := llvmgen.NewLLVMBuilder(opts.llvm)
 	llvm.GenerateFromTAC(builder.Instructions())
-	irop := llvm.IR()
+	if report(llvm.Diagnostics) {
+		return exitCodegenError
+	}
+	if opts.emit == emitBuild {
+		return build(llvm.IR().String(), opts.build, stderr, report)
+	}
+	return writeOutput(out, stderr, llvm.IR().String())
+}
 
-	outFile, _ := os.Create("output.ll")
-	defer func(outFile *os.File) {
-		err := outFile.Close()
-		if err != nil {
-			panic(err)
+func writeOutput(out, stderr io.Writer, text string) int {
+	if _, err := io.WriteString(out, text); err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitIOError
+	}
+	return exitOK
+}
+
+// interpret выполняет программу; ошибка выполнения печатается со стеком вызовов
+func interpret(program *ast.StatementsNode, out io.Writer, maxDepth int, report func(diagnostics.List) bool) int {
+	in := interp.New(out)
+	in.MaxCallDepth = maxDepth
+	if err := in.Run(program); err != nil {
+		d, ok := err.(diagnostics.Diagnostic)
+		if !ok {
+			d = diagnostics.Errorf(diagnostics.NoSpan, diagnostics.CodeRuntimeError, "%v", err)
 		}
-	}(outFile)
-	_, err = outFile.WriteString(irop.String())
-	if err != nil {
-		panic(err)
+		report(diagnostics.List{d})
+		return exitRuntimeError
 	}
+	// Код из exit(code) становится кодом завершения, как у скомпилированной программы
+	return in.ExitCode()
+}
 
+// build собирает исполняемый или объектный файл внешними инструментами.
+// Если их нет, IR записывается рядом с результатом в файл .ll, чтобы его
+// можно было собрать вручную.
+func build(ir string, cfg backend.Config, stderr io.Writer, report func(diagnostics.List) bool) int {
+	tc, ok := backend.FindToolchain()
+	if !ok {
+		path := fallbackIRPath(cfg.Output)
+		if err := os.WriteFile(path, []byte(ir), 0o644); err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitIOError
+		}
+		report(diagnostics.List{diagnostics.Warningf(diagnostics.NoSpan, diagnostics.CodeToolchainMissing,
+			"не найден clang или llc с cc: машинный код не собран, LLVM IR записан в %s", path).
+			WithNote("установите clang (или llc и cc) либо соберите %s вручную", path)})
+		return exitOK
+	}
+	if err := tc.Build(ir, cfg); err != nil {
+		d, ok := err.(diagnostics.Diagnostic)
+		if !ok {
+			d = diagnostics.Errorf(diagnostics.NoSpan, diagnostics.CodeToolchainFailed, "%v", err)
+		}
+		report(diagnostics.List{d.WithNote("сборка через %s", tc)})
+		return exitBuildError
+	}
+	return exitOK
 }
@@ -0,0 +1,50 @@
+package diagnostics
+
+// Коды диагностик. Первая буква — стадия: L — лексер, P — парсер,
+// S — семантика, T — трёхадресный код, G — генерация LLVM IR, R — выполнение в интерпретаторе,
+// B — сборка машинного кода внешними инструментами.
+const (
+	CodeUnexpectedChar      = "L0001"
+	CodeUnterminatedString  = "L0002"
+	CodeUnterminatedComment = "L0003"
+	CodeInvalidEscape       = "L0004"
+	CodeIntOverflow         = "L0005"
+	CodeMalformedNumber     = "L0006"
+
+	CodeUnexpectedToken    = "P0001"
+	CodeExpectedExpression = "P0002"
+	CodeUnexpectedEOF      = "P0003"
+
+	CodeUndefinedVariable   = "S0001"
+	CodeTypeMismatch        = "S0002"
+	CodeInvalidOperands     = "S0003"
+	CodeConditionType       = "S0004"
+	CodeUndefinedFunction   = "S0005"
+	CodeArgumentCount       = "S0006"
+	CodeArgumentType        = "S0007"
+	CodeReturnType          = "S0008"
+	CodeUnknownNode         = "S0009"
+	CodeRedeclaration       = "S0010"
+	CodeReturnOutside       = "S0011"
+	CodeMissingReturn       = "S0012"
+	CodeNestedFunction      = "S0013"
+	CodeUninitializedGlobal = "S0014"
+
+	CodeUnsupportedNode = "T0001"
+	CodeDivisionByZero  = "T0002"
+
+	CodeUnknownValue    = "G0001"
+	CodeUnsupportedType = "G0002"
+	CodeMalformedTAC    = "G0003"
+	CodeReservedName    = "G0004"
+	CodeUnknownTarget   = "G0005"
+
+	CodeRuntimeError   = "R0001"
+	CodeArityMismatch  = "R0002"
+	CodeRecursionLimit = "R0003"
+	CodeRuntimeType    = "R0004"
+	CodeZeroDivision   = "R0005"
+
+	CodeToolchainFailed  = "B0001"
+	CodeToolchainMissing = "B0002"
+)
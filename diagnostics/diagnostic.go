@@ -0,0 +1,129 @@
+package diagnostics
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Severity — уровень серьёзности диагностики
+type Severity int
+
+const (
+	Error Severity = iota
+	Warning
+	Note
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Error:
+		return "error"
+	case Warning:
+		return "warning"
+	case Note:
+		return "note"
+	default:
+		return fmt.Sprintf("severity(%d)", int(s))
+	}
+}
+
+// Span — полуинтервал [Start, End) байтовых смещений в исходном тексте (как lexer.Token.Pos)
+type Span struct {
+	Start int
+	End   int
+}
+
+// NoSpan используется там, где позиция в исходнике неизвестна (например, в llvmgen)
+var NoSpan = Span{Start: -1, End: -1}
+
+func (s Span) IsValid() bool {
+	return s.Start >= 0 && s.End >= s.Start
+}
+
+// Merge возвращает минимальный участок, покрывающий оба спана
+func (s Span) Merge(other Span) Span {
+	if !s.IsValid() {
+		return other
+	}
+	if !other.IsValid() {
+		return s
+	}
+	if other.Start < s.Start {
+		s.Start = other.Start
+	}
+	if other.End > s.End {
+		s.End = other.End
+	}
+	return s
+}
+
+// Diagnostic — одно сообщение компилятора, привязанное к участку исходника
+type Diagnostic struct {
+	Severity Severity
+	Span     Span
+	Code     string
+	Message  string
+	Notes    []string
+	Related  []Diagnostic // связанные места исходника (например, предыдущее объявление)
+}
+
+// Errorf создаёт диагностику уровня Error
+func Errorf(span Span, code string, format string, args ...interface{}) Diagnostic {
+	return Diagnostic{Severity: Error, Span: span, Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// Warningf создаёт диагностику уровня Warning
+func Warningf(span Span, code string, format string, args ...interface{}) Diagnostic {
+	return Diagnostic{Severity: Warning, Span: span, Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// WithNote возвращает копию диагностики с дополнительным примечанием
+func (d Diagnostic) WithNote(format string, args ...interface{}) Diagnostic {
+	d.Notes = append(append([]string(nil), d.Notes...), fmt.Sprintf(format, args...))
+	return d
+}
+
+// WithRelated возвращает копию диагностики со ссылкой на другое место исходника
+func (d Diagnostic) WithRelated(span Span, format string, args ...interface{}) Diagnostic {
+	related := Diagnostic{Severity: Note, Span: span, Message: fmt.Sprintf(format, args...)}
+	d.Related = append(append([]Diagnostic(nil), d.Related...), related)
+	return d
+}
+
+// Error позволяет возвращать диагностику как обычную ошибку Go
+func (d Diagnostic) Error() string {
+	var sb strings.Builder
+	sb.WriteString(d.Severity.String())
+	if d.Code != "" {
+		fmt.Fprintf(&sb, "[%s]", d.Code)
+	}
+	sb.WriteString(": ")
+	sb.WriteString(d.Message)
+	return sb.String()
+}
+
+// List — накопитель диагностик одной стадии
+type List []Diagnostic
+
+func (l *List) Add(d Diagnostic) {
+	*l = append(*l, d)
+}
+
+func (l List) HasErrors() bool {
+	for _, d := range l {
+		if d.Severity == Error {
+			return true
+		}
+	}
+	return false
+}
+
+// Shift сдвигает все спаны на offset (используется при склейке нескольких файлов)
+func (l List) Shift(offset int) {
+	for i := range l {
+		if l[i].Span.IsValid() {
+			l[i].Span.Start += offset
+			l[i].Span.End += offset
+		}
+	}
+}
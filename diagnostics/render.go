@@ -0,0 +1,88 @@
+package diagnostics
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// Render печатает диагностику в формате "файл:строка:столбец: error[код]: сообщение",
+// затем строку исходника с подчёркиванием и примечания.
+// f может быть nil — тогда выводится только сообщение.
+func Render(w io.Writer, f *File, d Diagnostic) {
+	renderOne(w, f, d)
+	for _, related := range d.Related {
+		Render(w, f, related)
+	}
+}
+
+func renderOne(w io.Writer, f *File, d Diagnostic) {
+	if f == nil || !d.Span.IsValid() {
+		if f != nil {
+			fmt.Fprintf(w, "%s: ", f.Name)
+		}
+		fmt.Fprintln(w, d.Error())
+		renderNotes(w, d, "")
+		return
+	}
+
+	start := d.Span.Start - f.Base
+	end := d.Span.End - f.Base
+	line, col := f.Position(start)
+	fmt.Fprintf(w, "%s:%d:%d: %s\n", f.Name, line, col, d.Error())
+
+	text := f.Line(line)
+	gutter := fmt.Sprintf("%d", line)
+	pad := strings.Repeat(" ", len(gutter))
+	fmt.Fprintf(w, " %s | %s\n", gutter, text)
+	fmt.Fprintf(w, " %s | %s\n", pad, underline(text, col, f, start, end))
+	renderNotes(w, d, pad)
+}
+
+// Render печатает диагностику, сам находя нужный файл по смещению
+func (fs *FileSet) Render(w io.Writer, d Diagnostic) {
+	var f *File
+	if d.Span.IsValid() {
+		f = fs.File(d.Span.Start)
+	}
+	renderOne(w, f, d)
+	for _, related := range d.Related {
+		fs.Render(w, related)
+	}
+}
+
+func renderNotes(w io.Writer, d Diagnostic, pad string) {
+	for _, note := range d.Notes {
+		fmt.Fprintf(w, " %s = note: %s\n", pad, note)
+	}
+}
+
+// underline строит строку с "^~~~" под участком [start, end), сохраняя табы,
+// чтобы подчёркивание совпадало с исходником
+func underline(text string, col int, f *File, start, end int) string {
+	var sb strings.Builder
+	prefix := []rune(text)
+	for i := 0; i < col-1 && i < len(prefix); i++ {
+		if prefix[i] == '\t' {
+			sb.WriteByte('\t')
+		} else {
+			sb.WriteByte(' ')
+		}
+	}
+
+	// Подчёркиваем только до конца строки
+	lineEnd := start + len(text) - len(string(prefix[:min(col-1, len(prefix))]))
+	if end > lineEnd {
+		end = lineEnd
+	}
+	width := 1
+	if end > start && end <= len(f.Text) {
+		width = utf8.RuneCountInString(f.Text[start:end])
+	}
+	sb.WriteByte('^')
+	if width > 1 {
+		sb.WriteString(strings.Repeat("~", width-1))
+	}
+	return sb.String()
+}
@@ -0,0 +1,83 @@
+package diagnostics
+
+import (
+	"sort"
+	"unicode/utf8"
+)
+
+// File — исходный файл, для которого считаются строки и столбцы
+type File struct {
+	Name string
+	Text string
+	Base int // смещение файла в общем адресном пространстве FileSet
+
+	lineStarts []int
+}
+
+func NewFile(name, text string) *File {
+	f := &File{Name: name, Text: text, lineStarts: []int{0}}
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\n' {
+			f.lineStarts = append(f.lineStarts, i+1)
+		}
+	}
+	return f
+}
+
+// Position переводит смещение внутри файла в строку и столбец (оба с единицы, столбец — в символах)
+func (f *File) Position(offset int) (line, col int) {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > len(f.Text) {
+		offset = len(f.Text)
+	}
+	idx := sort.Search(len(f.lineStarts), func(i int) bool { return f.lineStarts[i] > offset }) - 1
+	return idx + 1, utf8.RuneCountInString(f.Text[f.lineStarts[idx]:offset]) + 1
+}
+
+// Line возвращает текст строки с номером line (с единицы) без перевода строки
+func (f *File) Line(line int) string {
+	if line < 1 || line > len(f.lineStarts) {
+		return ""
+	}
+	start := f.lineStarts[line-1]
+	end := len(f.Text)
+	if line < len(f.lineStarts) {
+		end = f.lineStarts[line] - 1
+	}
+	if end > start && f.Text[end-1] == '\r' {
+		end--
+	}
+	return f.Text[start:end]
+}
+
+// FileSet раскладывает несколько файлов в одно адресное пространство,
+// чтобы позиции токенов из разных файлов не пересекались
+type FileSet struct {
+	files []*File
+	next  int
+}
+
+func NewFileSet() *FileSet {
+	return &FileSet{}
+}
+
+// AddFile добавляет файл; позиции его токенов нужно сдвинуть на File.Base
+func (fs *FileSet) AddFile(name, text string) *File {
+	f := NewFile(name, text)
+	f.Base = fs.next
+	fs.next += len(text) + 1
+	fs.files = append(fs.files, f)
+	return f
+}
+
+// File находит файл, которому принадлежит смещение
+func (fs *FileSet) File(offset int) *File {
+	for i := len(fs.files) - 1; i >= 0; i-- {
+		if offset >= fs.files[i].Base {
+			return fs.files[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+package interp
+
+import "compiler_project/parser/ast"
+
+// builtinFunc — реализация встроенной функции; число и типы аргументов
+// уже сверены с сигнатурой из semantics.Builtins
+type builtinFunc func(in *Interpreter, n *ast.FunctionCallNode, args []Value) Value
+
+var builtinImpls = map[string]builtinFunc{
+	"exit": builtinExit,
+}
+
+// exitSignal останавливает программу; Run перехватывает его как обычное завершение
+type exitSignal struct {
+	code int32
+}
+
+func builtinExit(in *Interpreter, n *ast.FunctionCallNode, args []Value) Value {
+	panic(exitSignal{code: args[0].Int})
+}
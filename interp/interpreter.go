@@ -0,0 +1,476 @@
+package interp
+
+import (
+	"compiler_project/diagnostics"
+	"compiler_project/lexer"
+	"compiler_project/parser/ast"
+	"compiler_project/semantics"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// Interpreter выполняет AST напрямую, без генерации кода. Программа обычно уже
+// прошла checker, но интерпретатор не полагается на это: несовпадение типов,
+// деление на ноль и прочие ошибки возвращаются из Run как диагностики.
+type Interpreter struct {
+	Stdout       io.Writer // куда печатает show
+	MaxCallDepth int       // предел вложенности вызовов; 0 — без ограничения
+
+	globals *semantics.SymbolTable
+	scope   *semantics.SymbolTable // текущая область видимости
+	frames  []callFrame            // стек вызовов
+
+	exitCode int32 // код, переданный в exit
+}
+
+// New создаёт интерпретатор, печатающий в stdout (nil — os.Stdout)
+func New(stdout io.Writer) *Interpreter {
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+	globals := semantics.NewSymbolTable(nil)
+	for i := range semantics.Builtins {
+		sym := semantics.Builtins[i]
+		sym.Value = builtinImpls[sym.Name]
+		globals.Declare(&sym)
+	}
+	return &Interpreter{
+		Stdout:       stdout,
+		MaxCallDepth: DefaultMaxCallDepth,
+		globals:      globals,
+		scope:        globals,
+	}
+}
+
+// Run выполняет программу. Повторный вызов продолжает работу с теми же
+// глобальными переменными и функциями.
+func (in *Interpreter) Run(program ast.ExpressionNode) (err error) {
+	defer func() {
+		r := recover()
+		if exit, ok := r.(exitSignal); ok {
+			in.exitCode = exit.code
+			in.frames = nil
+			in.scope = in.globals
+			return
+		}
+		if r != nil {
+			err = in.recoverError(r)
+		}
+	}()
+	if _, returned := in.exec(program); returned {
+		in.fail(program, diagnostics.CodeRuntimeError, "return вне функции")
+	}
+	return nil
+}
+
+// ExitCode возвращает код, с которым программа вызвала exit (0, если не вызывала)
+func (in *Interpreter) ExitCode() int {
+	return int(in.exitCode)
+}
+
+// Global возвращает значение глобальной переменной
+func (in *Interpreter) Global(name string) (Value, bool) {
+	sym, ok := in.globals.LookupLocal(name)
+	if !ok {
+		return Void, false
+	}
+	v, ok := sym.Value.(Value)
+	return v, ok
+}
+
+// exec выполняет инструкцию. returned сообщает, что выполнен return:
+// он прерывает все объемлющие блоки до вызова функции.
+func (in *Interpreter) exec(node ast.ExpressionNode) (result Value, returned bool) {
+	switch n := node.(type) {
+	case *ast.StatementsNode:
+		// Функции верхнего уровня объявляются до выполнения программы,
+		// чтобы их можно было вызывать выше по тексту
+		if in.scope == in.globals {
+			for _, stmt := range n.CodeStrings {
+				if fn, ok := stmt.(*ast.FunctionDeclarationNode); ok {
+					in.declareFunction(fn)
+				}
+			}
+		}
+		for _, stmt := range n.CodeStrings {
+			if result, returned = in.exec(stmt); returned {
+				return result, true
+			}
+		}
+		return Void, false
+
+	case *ast.IfNode:
+		if in.condition(n.Condition, "if") {
+			return in.execBlock(n.TrueBranch)
+		}
+		if n.FalseBranch != nil {
+			return in.execBlock(n.FalseBranch)
+		}
+		return Void, false
+
+	case *ast.WhileNode:
+		for in.condition(n.Condition, "while") {
+			// Каждая итерация получает свою область: int t = ... в теле не конфликтует с прошлой
+			if result, returned = in.execBlock(n.Body); returned {
+				return result, true
+			}
+		}
+		return Void, false
+
+	case *ast.ReturnNode:
+		if n.Value == nil {
+			return Void, true
+		}
+		return in.eval(n.Value), true
+
+	case *ast.ShowNode:
+		val := in.eval(n.Variable)
+		fmt.Fprintf(in.Stdout, ">> %s\n", val)
+		return Void, false
+
+	case *ast.FunctionDeclarationNode:
+		if in.scope != in.globals {
+			in.fail(n, diagnostics.CodeRuntimeError, "функция %s объявлена не на верхнем уровне", n.Name.Text)
+		}
+		in.declareFunction(n)
+		return Void, false
+
+	default:
+		return in.eval(node), false
+	}
+}
+
+// execBlock выполняет тело if или while во вложенной области видимости
+func (in *Interpreter) execBlock(block *ast.StatementsNode) (Value, bool) {
+	outer := in.scope
+	in.scope = semantics.NewSymbolTable(outer)
+	defer func() { in.scope = outer }()
+	return in.exec(block)
+}
+
+// condition вычисляет условие if или while
+func (in *Interpreter) condition(node ast.ExpressionNode, stmt string) bool {
+	cond := in.eval(node)
+	if cond.Kind != BoolKind {
+		in.fail(node, diagnostics.CodeRuntimeType, "условие в %s должно быть boolean, получено: %s", stmt, cond.Kind)
+	}
+	return cond.Bool
+}
+
+func (in *Interpreter) declareFunction(n *ast.FunctionDeclarationNode) {
+	sym, ok := in.globals.Declare(&semantics.Symbol{Name: n.Name.Text, Kind: semantics.SymbolFunction, Span: n.Name.Span(), Value: n})
+	if !ok && sym.Value != n {
+		in.fail(n, diagnostics.CodeRuntimeError, "имя %s уже объявлено", n.Name.Text)
+	}
+}
+
+// eval вычисляет выражение
+func (in *Interpreter) eval(node ast.ExpressionNode) Value {
+	switch n := node.(type) {
+	case *ast.NumberNode:
+		val, err := lexer.ParseIntLiteral(n.Number.Text)
+		if err != nil {
+			in.fail(n, diagnostics.CodeRuntimeError, "некорректное целое %s: %v", n.Number.Text, err)
+		}
+		return IntValue(int32(val))
+
+	case *ast.FloatNode:
+		val, err := lexer.ParseFloatLiteral(n.Float.Text)
+		if err != nil {
+			in.fail(n, diagnostics.CodeRuntimeError, "некорректное вещественное %s: %v", n.Float.Text, err)
+		}
+		return DoubleValue(val)
+
+	case *ast.StringNode:
+		return StringValue(n.String.Value)
+
+	case *ast.BooleanNode:
+		return BoolValue(n.Boolean.TypeToken.Name == "TRUE")
+
+	case *ast.VariableNode:
+		return in.lookup(n, n.Variable.Text).Value.(Value)
+
+	case *ast.TypedAssignNode:
+		// Значение вычисляется до объявления: в int a = a + 1 справа внешняя a
+		val := in.eval(n.Value)
+		if declared := n.Type.Type; declared != val.Kind.String() {
+			in.fail(n.Value, diagnostics.CodeRuntimeType, "тип переменной %s задан как %s, но присваивается %s", n.Variable.Text, declared, val.Kind)
+		}
+		sym := &semantics.Symbol{Name: n.Variable.Text, Kind: semantics.SymbolVariable, Type: n.Type.Type, Span: n.Variable.Span(), Value: val}
+		if _, ok := in.scope.Declare(sym); !ok {
+			in.fail(n, diagnostics.CodeRuntimeError, "переменная %s уже объявлена в этой области видимости", n.Variable.Text)
+		}
+		return val
+
+	case *ast.AssignNode:
+		val := in.eval(n.Value)
+		sym := in.lookup(n, n.Variable.Text)
+		if old := sym.Value.(Value); old.Kind != val.Kind {
+			in.fail(n.Value, diagnostics.CodeRuntimeType, "переменная %s имеет тип %s, но присваивается %s", n.Variable.Text, old.Kind, val.Kind)
+		}
+		sym.Value = val
+		return val
+
+	case *ast.BinOperationNode:
+		left := in.eval(n.LeftNode)
+		// and и or вычисляются с коротким замыканием: правый операнд
+		// не выполняется, если левый уже определил результат
+		if op := n.Operator.TypeToken.Operator(); (op == "and" || op == "or") && left.Kind == BoolKind && left.Bool == (op == "or") {
+			return left
+		}
+		right := in.eval(n.RightNode)
+		return in.binary(n, left, right)
+
+	case *ast.UnarOperationNode:
+		operand := in.eval(n.Operand)
+		switch op := n.Operator.TypeToken.Operator(); {
+		case op == "-" && operand.Kind == IntKind:
+			return IntValue(-operand.Int)
+		case op == "-" && operand.Kind == DoubleKind:
+			return DoubleValue(-operand.Double)
+		case op == "not" && operand.Kind == BoolKind:
+			return BoolValue(!operand.Bool)
+		}
+		in.fail(n, diagnostics.CodeRuntimeType, "унарная операция %s не определена для типа %s", n.Operator.Text, operand.Kind)
+
+	case *ast.FunctionCallNode:
+		return in.call(n)
+
+	case *ast.StatementsNode, *ast.IfNode, *ast.WhileNode, *ast.ReturnNode, *ast.ShowNode, *ast.FunctionDeclarationNode:
+		in.fail(n, diagnostics.CodeRuntimeError, "инструкция %T использована как выражение", n)
+
+	default:
+		// TypeNode и ParamNode встречаются только внутри объявлений
+		in.fail(node, diagnostics.CodeRuntimeError, "неизвестный тип AST узла: %T", node)
+	}
+	return Void
+}
+
+// lookup находит переменную или параметр в текущей области
+func (in *Interpreter) lookup(node ast.ExpressionNode, name string) *semantics.Symbol {
+	sym, ok := in.scope.Lookup(name)
+	if !ok {
+		in.fail(node, diagnostics.CodeRuntimeError, "переменная %s не определена", name)
+	}
+	if sym.Kind == semantics.SymbolFunction {
+		in.fail(node, diagnostics.CodeRuntimeType, "%s — функция, а не переменная", name)
+	}
+	return sym
+}
+
+func (in *Interpreter) call(n *ast.FunctionCallNode) Value {
+	sym, ok := in.scope.Lookup(n.Name.Text)
+	if !ok || sym.Kind != semantics.SymbolFunction {
+		in.fail(n, diagnostics.CodeRuntimeError, "функция %s не найдена", n.Name.Text)
+	}
+	builtin, _ := sym.Value.(builtinFunc)
+	fn, _ := sym.Value.(*ast.FunctionDeclarationNode)
+
+	paramTypes := sym.Params
+	if fn != nil {
+		paramTypes = make([]string, len(fn.Params))
+		for i, param := range fn.Params {
+			paramTypes[i] = param.Type.Type
+		}
+	}
+	if len(n.Arguments) != len(paramTypes) {
+		in.fail(n, diagnostics.CodeArityMismatch, "функция %s ожидает %d аргументов, получено %d", n.Name.Text, len(paramTypes), len(n.Arguments))
+	}
+
+	// Аргументы вычисляются в области вызывающего до открытия кадра
+	args := make([]Value, len(n.Arguments))
+	for i, arg := range n.Arguments {
+		args[i] = in.eval(arg)
+		if want := paramTypes[i]; args[i].Kind.String() != want {
+			in.fail(arg, diagnostics.CodeRuntimeType, "в функции %s аргумент %d имеет тип %s, ожидался %s", n.Name.Text, i+1, args[i].Kind, want)
+		}
+	}
+
+	if builtin != nil {
+		return builtin(in, n, args)
+	}
+	if fn == nil {
+		in.fail(n, diagnostics.CodeRuntimeError, "функция %s не найдена", n.Name.Text)
+	}
+
+	// Кадр вызова — новая область поверх глобальной: функция видит глобальные
+	// переменные, но не локальные вызывающего, а параметры рекурсивного вызова
+	// не затирают параметры внешнего
+	callScope := semantics.NewSymbolTable(in.globals)
+	for i, param := range fn.Params {
+		callScope.Declare(&semantics.Symbol{Name: param.Name.Text, Kind: semantics.SymbolParameter, Type: param.Type.Type, Span: param.Name.Span(), Value: args[i]})
+	}
+
+	// Кадр снимается только при нормальном выходе: после ошибки стек
+	// остаётся нетронутым для трассировки
+	in.pushFrame(n)
+	outer := in.scope
+	in.scope = callScope
+	result, _ := in.exec(fn.Body)
+	in.scope = outer
+	in.popFrame()
+
+	returnType := "void"
+	if fn.ReturnType != nil {
+		returnType = fn.ReturnType.Type
+	}
+	if result.Kind.String() != returnType {
+		in.fail(n, diagnostics.CodeRuntimeType, "функция %s должна вернуть %s, но вернула %s", n.Name.Text, returnType, result.Kind)
+	}
+	return result
+}
+
+// binary выполняет бинарную операцию. Операнды должны иметь один тип,
+// кроме возведения вещественного числа в целую степень.
+func (in *Interpreter) binary(n *ast.BinOperationNode, left, right Value) Value {
+	op := n.Operator.TypeToken.Operator()
+	if op == "**" && left.Kind == DoubleKind && right.Kind == IntKind {
+		return DoubleValue(math.Pow(left.Double, float64(right.Int)))
+	}
+	if left.Kind != right.Kind {
+		in.fail(n, diagnostics.CodeRuntimeType, "операция %s не определена для типов %s и %s", n.Operator.Text, left.Kind, right.Kind)
+	}
+
+	switch left.Kind {
+	case IntKind:
+		if v, ok := in.intBinary(n, op, left.Int, right.Int); ok {
+			return v
+		}
+	case DoubleKind:
+		if v, ok := floatBinary(op, left.Double, right.Double); ok {
+			return v
+		}
+	case StringKind:
+		l, r := left.Str, right.Str
+		switch op {
+		case "+":
+			return StringValue(l + r)
+		case "equal":
+			return BoolValue(l == r)
+		case "non-equal":
+			return BoolValue(l != r)
+		case "less":
+			return BoolValue(l < r)
+		case "more":
+			return BoolValue(l > r)
+		case "less-or-equal":
+			return BoolValue(l <= r)
+		case "more-or-equal":
+			return BoolValue(l >= r)
+		}
+	case BoolKind:
+		l, r := left.Bool, right.Bool
+		switch op {
+		case "equal":
+			return BoolValue(l == r)
+		case "non-equal":
+			return BoolValue(l != r)
+		case "and":
+			return BoolValue(l && r)
+		case "or":
+			return BoolValue(l || r)
+		}
+	}
+	in.fail(n, diagnostics.CodeRuntimeType, "операция %s не определена для типа %s", n.Operator.Text, left.Kind)
+	return Void
+}
+
+// intBinary считает в int32 с теми же правилами, что LLVM и свёртка констант в TAC
+func (in *Interpreter) intBinary(n *ast.BinOperationNode, op string, l, r int32) (Value, bool) {
+	switch op {
+	case "+":
+		return IntValue(l + r), true
+	case "-":
+		return IntValue(l - r), true
+	case "*":
+		return IntValue(l * r), true
+	case "/", "%":
+		if r == 0 {
+			in.fail(n, diagnostics.CodeZeroDivision, "целочисленное деление на ноль")
+		}
+		if op == "/" {
+			return IntValue(l / r), true
+		}
+		return IntValue(l % r), true
+	case "**":
+		// При отрицательном показателе 1 / l**-r усекается к нулю:
+		// целым остаётся только степень 1 и -1
+		if r < 0 {
+			switch {
+			case l == 1:
+				return IntValue(1), true
+			case l == -1 && r%2 == 0:
+				return IntValue(1), true
+			case l == -1:
+				return IntValue(-1), true
+			}
+			return IntValue(0), true
+		}
+		result := int32(1)
+		for base := l; r > 0; r >>= 1 {
+			if r&1 == 1 {
+				result *= base
+			}
+			base *= base
+		}
+		return IntValue(result), true
+	case "&":
+		return IntValue(l & r), true
+	case "|":
+		return IntValue(l | r), true
+	case "^":
+		return IntValue(l ^ r), true
+	case "<<":
+		// Сдвиг берётся по модулю 32, как в LLVM
+		return IntValue(l << (uint32(r) & 31)), true
+	case ">>":
+		return IntValue(l >> (uint32(r) & 31)), true
+	case "equal":
+		return BoolValue(l == r), true
+	case "non-equal":
+		return BoolValue(l != r), true
+	case "less":
+		return BoolValue(l < r), true
+	case "more":
+		return BoolValue(l > r), true
+	case "less-or-equal":
+		return BoolValue(l <= r), true
+	case "more-or-equal":
+		return BoolValue(l >= r), true
+	}
+	return Void, false
+}
+
+// floatBinary следует IEEE 754: деление на ноль даёт бесконечность,
+// non-equal истинен для NaN
+func floatBinary(op string, l, r float64) (Value, bool) {
+	switch op {
+	case "+":
+		return DoubleValue(l + r), true
+	case "-":
+		return DoubleValue(l - r), true
+	case "*":
+		return DoubleValue(l * r), true
+	case "/":
+		return DoubleValue(l / r), true
+	case "%":
+		return DoubleValue(math.Mod(l, r)), true
+	case "**":
+		return DoubleValue(math.Pow(l, r)), true
+	case "equal":
+		return BoolValue(l == r), true
+	case "non-equal":
+		return BoolValue(l != r), true
+	case "less":
+		return BoolValue(l < r), true
+	case "more":
+		return BoolValue(l > r), true
+	case "less-or-equal":
+		return BoolValue(l <= r), true
+	case "more-or-equal":
+		return BoolValue(l >= r), true
+	}
+	return Void, false
+}
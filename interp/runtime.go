@@ -0,0 +1,78 @@
+package interp
+
+import (
+	"compiler_project/diagnostics"
+	"compiler_project/parser/ast"
+)
+
+// DefaultMaxCallDepth — предел вложенности вызовов по умолчанию
+const DefaultMaxCallDepth = 1000
+
+// maxTraceFrames — сколько последних вызовов показывать в трассировке стека
+const maxTraceFrames = 10
+
+// callFrame — активный вызов функции
+type callFrame struct {
+	name string
+	call diagnostics.Span // место вызова в исходнике
+}
+
+// fail прерывает выполнение ошибкой, привязанной к узлу.
+// Паника перехватывается в Run и превращается в диагностику со стеком вызовов.
+func (in *Interpreter) fail(node ast.ExpressionNode, code string, format string, args ...interface{}) {
+	panic(diagnostics.Errorf(ast.SpanOf(node), code, format, args...))
+}
+
+// recoverError превращает панику выполнения в диагностику и сбрасывает состояние
+// на верхний уровень; глобальные переменные сохраняются
+func (in *Interpreter) recoverError(r interface{}) error {
+	d, ok := r.(diagnostics.Diagnostic)
+	if !ok {
+		// Ошибка внутри Go — значит, интерпретатор что-то не предусмотрел
+		d = diagnostics.Errorf(diagnostics.NoSpan, diagnostics.CodeRuntimeError, "внутренняя ошибка интерпретатора: %v", r)
+	}
+	d = in.withStackTrace(d)
+	in.frames = nil
+	in.scope = in.globals
+	return d
+}
+
+// withStackTrace добавляет к ошибке места вызовов, начиная с самого внутреннего.
+// Подряд идущие вызовы из одного места (рекурсия) показываются один раз.
+func (in *Interpreter) withStackTrace(d diagnostics.Diagnostic) diagnostics.Diagnostic {
+	shown := 0
+	for i := len(in.frames) - 1; i >= 0; {
+		if shown == maxTraceFrames {
+			d = d.WithRelated(diagnostics.NoSpan, "... и ещё %d вызовов", i+1)
+			break
+		}
+		frame := in.frames[i]
+		repeats := 0
+		for i--; i >= 0 && in.frames[i] == frame; i-- {
+			repeats++
+		}
+		d = d.WithRelated(frame.call, "в вызове %s", frame.name)
+		if repeats > 0 {
+			d = d.WithRelated(diagnostics.NoSpan, "тот же вызов повторяется ещё %d раз", repeats)
+		}
+		shown++
+	}
+	return d
+}
+
+// pushFrame открывает кадр вызова, проверяя глубину рекурсии
+func (in *Interpreter) pushFrame(n *ast.FunctionCallNode) {
+	if in.MaxCallDepth > 0 && len(in.frames) >= in.MaxCallDepth {
+		in.fail(n, diagnostics.CodeRecursionLimit, "превышена максимальная глубина вызовов (%d) при вызове %s", in.MaxCallDepth, n.Name.Text)
+	}
+	in.frames = append(in.frames, callFrame{name: n.Name.Text, call: ast.SpanOf(n)})
+}
+
+func (in *Interpreter) popFrame() {
+	in.frames = in.frames[:len(in.frames)-1]
+}
+
+// StackDepth возвращает число активных вызовов функций
+func (in *Interpreter) StackDepth() int {
+	return len(in.frames)
+}
@@ -0,0 +1,104 @@
+package interp
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Kind — тип значения во время выполнения; совпадает с типами языка
+type Kind int
+
+const (
+	VoidKind Kind = iota
+	IntKind
+	DoubleKind
+	StringKind
+	BoolKind
+)
+
+func (k Kind) String() string {
+	switch k {
+	case IntKind:
+		return "int"
+	case DoubleKind:
+		return "double"
+	case StringKind:
+		return "string"
+	case BoolKind:
+		return "boolean"
+	default:
+		return "void"
+	}
+}
+
+// Value — значение во время выполнения. Заполнено только поле, соответствующее Kind.
+// int хранится как int32: переполнение заворачивается так же, как в скомпилированном коде.
+type Value struct {
+	Kind   Kind
+	Int    int32
+	Double float64
+	Str    string
+	Bool   bool
+}
+
+// Void — результат инструкций и функций без значения
+var Void = Value{}
+
+func IntValue(v int32) Value {
+	return Value{Kind: IntKind, Int: v}
+}
+
+func DoubleValue(v float64) Value {
+	return Value{Kind: DoubleKind, Double: v}
+}
+
+func StringValue(v string) Value {
+	return Value{Kind: StringKind, Str: v}
+}
+
+func BoolValue(v bool) Value {
+	return Value{Kind: BoolKind, Bool: v}
+}
+
+// String возвращает значение в том виде, в каком его печатает show
+func (v Value) String() string {
+	switch v.Kind {
+	case IntKind:
+		return strconv.FormatInt(int64(v.Int), 10)
+	case DoubleKind:
+		return formatDouble(v.Double)
+	case StringKind:
+		return v.Str
+	case BoolKind:
+		return strconv.FormatBool(v.Bool)
+	default:
+		return "<void>"
+	}
+}
+
+// GoString нужен для %#v в сообщениях об ошибках и тестах
+func (v Value) GoString() string {
+	if v.Kind == StringKind {
+		return strconv.Quote(v.Str)
+	}
+	return fmt.Sprintf("%s(%s)", v.Kind, v)
+}
+
+// formatDouble печатает число как printf("%g") из libc, которым пользуется
+// скомпилированная программа: шесть значащих цифр, inf и nan строчными
+func formatDouble(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "inf"
+	case math.IsInf(v, -1):
+		return "-inf"
+	case math.IsNaN(v):
+		// glibc печатает знак NaN; 0.0/0.0 на x86 даёт NaN с установленным знаком
+		if math.Signbit(v) {
+			return "-nan"
+		}
+		return "nan"
+	}
+	return strconv.FormatFloat(v, 'g', 6, 64)
+}
@@ -1,14 +1,37 @@
 package lexer
 
 import (
-	"fmt"
-	"regexp"
+	"compiler_project/diagnostics"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
 )
 
+// Lexer — однопроходный сканер: каждый символ просматривается один раз,
+// и ничего не компилируется во время работы. Регулярные выражения из
+// TokenTypesOrdered описывают токены лишь приблизительно; эталонный лексер на них
+// (regexpLexer в tests) совпадает с этим на операторах, строках и простых числах,
+// но отличается в остальном:
+//   - идентификатор читается целиком (interval, order, showCount) и только потом
+//     сверяется с таблицей ключевых слов Keywords;
+//   - комментарии // и /* */ (в том числе вложенные) пропускаются или сохраняются
+//     как trivia;
+//   - числа записываются и в 0x, 0b, 0o, с _ и с экспонентой; диапазон int
+//     проверяет парсер, потому что 2147483648 допустим после унарного минуса;
+//   - в строках декодируются escape-последовательности (Token.Value), неверные
+//     сообщаются диагностикой.
 type Lexer struct {
-	code   string
-	pos    int
-	Tokens []Token
+	code        string
+	pos         int
+	Tokens      []Token
+	Diagnostics diagnostics.List
+
+	// KeepTrivia включает сохранение комментариев в Token.LeadingTrivia.
+	// Комментарии после последнего токена попадают в TrailingTrivia.
+	KeepTrivia     bool
+	TrailingTrivia []Trivia
 }
 
 func NewLexer(code string) *Lexer {
@@ -16,33 +39,358 @@ func NewLexer(code string) *Lexer {
 }
 
 func (l *Lexer) LexerAnalysis() *[]Token {
+	// Грубая оценка числа токенов, чтобы не перевыделять срез на больших входах
+	if l.Tokens == nil {
+		l.Tokens = make([]Token, 0, len(l.code)/4+1)
+	}
 	for l.nextToken() {
 	}
 
 	return &l.Tokens
 }
 
+// skipComment пропускает комментарий "// ..." до конца строки или "/* ... */"
+// (с поддержкой вложенности) так же, как пробелы. Возвращает false, если это не комментарий.
+func (l *Lexer) skipComment() bool {
+	start := l.pos
+	if !strings.HasPrefix(l.code[start:], "//") && !strings.HasPrefix(l.code[start:], "/*") {
+		return false
+	}
+
+	kind := LineComment
+	if l.code[start+1] == '/' {
+		end := strings.IndexByte(l.code[start:], '\n')
+		if end < 0 {
+			l.pos = len(l.code)
+		} else {
+			l.pos = start + end
+		}
+	} else {
+		kind = BlockComment
+		depth := 0
+		for l.pos < len(l.code) {
+			if strings.HasPrefix(l.code[l.pos:], "/*") {
+				depth++
+				l.pos += 2
+			} else if strings.HasPrefix(l.code[l.pos:], "*/") {
+				depth--
+				l.pos += 2
+				if depth == 0 {
+					break
+				}
+			} else {
+				l.pos++
+			}
+		}
+		if depth > 0 {
+			l.Diagnostics.Add(diagnostics.Errorf(
+				diagnostics.Span{Start: start, End: start + 2},
+				diagnostics.CodeUnterminatedComment,
+				"незакрытый комментарий",
+			))
+		}
+	}
+
+	if l.KeepTrivia {
+		l.TrailingTrivia = append(l.TrailingTrivia, Trivia{Kind: kind, Text: l.code[start:l.pos], Pos: start})
+	}
+	return true
+}
+
+var tokenTypes = *TokenTypeList
+
+var (
+	variableType = tokenTypes["VARIABLE"]
+	integerType  = tokenTypes["INTEGER"]
+	doubleType   = tokenTypes["DOUBLE"]
+	stringType   = tokenTypes["STRING"]
+)
+
+// Keywords — таблица ключевых слов (все типы из TokenTypesOrdered до VARIABLE).
+// Идентификатор, который лишь начинается с ключевого слова, ключевым словом не является.
+var Keywords = func() map[string]TokenType {
+	result := map[string]TokenType{}
+	for _, tt := range TokenTypesOrdered {
+		if tt.Name == "VARIABLE" {
+			break
+		}
+		result[tt.Regex] = tt
+	}
+	return result
+}()
+
+// hyphenatedKeywords — ключевые слова с дефисом (non-equal): целым идентификатором
+// их не прочитать, поэтому они проверяются отдельно, длинные первыми
+var hyphenatedKeywords = func() []TokenType {
+	var result []TokenType
+	for word, tt := range Keywords {
+		if strings.Contains(word, "-") {
+			result = append(result, tt)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if len(result[i].Regex) != len(result[j].Regex) {
+			return len(result[i].Regex) > len(result[j].Regex)
+		}
+		return result[i].Regex < result[j].Regex
+	})
+	return result
+}()
+
+// operators — операторы и разделители, сгруппированные по первому символу;
+// внутри группы длинные идут первыми (<= раньше <)
+var operators = func() [256][]TokenType {
+	var result [256][]TokenType
+	for _, name := range []string{
+		"EQ", "NEQ", "SHL", "SHR", "LE", "GE", "LAND", "LOR", "POWER",
+		"PLUSASSIGN", "MINUSASSIGN", "MULTIPLYASSIGN", "DIVIDEASSIGN", "MODULOASSIGN", "INCREMENT", "DECREMENT",
+		"ASSIGN", "PLUS", "MINUS", "MULTIPLY", "DIVIDE", "MODULO", "LT", "GT", "LNOT",
+		"BITAND", "BITOR", "BITXOR",
+		"LPAREN", "RPAREN", "LBRACE", "RBRACE", "SEMICOLON", "COMMA",
+	} {
+		tt := tokenTypes[name]
+		text := tt.Literal()
+		result[text[0]] = append(result[text[0]], tt)
+	}
+	return result
+}()
+
 func (l *Lexer) nextToken() bool {
 	if l.pos >= len(l.code) {
 		return false
 	}
 
-	for _, tokenType := range TokenTypesOrdered {
-		regex, err := regexp.Compile("^" + tokenType.Regex)
-		if err != nil {
-			panic(err)
+	start := l.pos
+	c := l.code[start]
+
+	switch {
+	case isWhitespace(c):
+		for l.pos < len(l.code) && isWhitespace(l.code[l.pos]) {
+			l.pos++
 		}
-		str := l.code[l.pos:]
-		match := regex.FindString(str)
-		if match != "" {
-			token := NewToken(tokenType, match, l.pos)
-			if token.TypeToken.Name != "WHITESPACE" {
-				l.Tokens = append(l.Tokens, *token)
+		return true
+
+	case isLetter(c):
+		for _, kw := range hyphenatedKeywords {
+			end := start + len(kw.Regex)
+			if strings.HasPrefix(l.code[start:], kw.Regex) && !l.isIdentAt(end) {
+				l.emit(kw, end)
+				return true
 			}
-			l.pos += len(match)
+		}
+		end := start + 1
+		for l.isIdentAt(end) {
+			end++
+		}
+		if kw, ok := Keywords[l.code[start:end]]; ok {
+			l.emit(kw, end)
+			return true
+		}
+		l.emit(variableType, end)
+		return true
+
+	case isDigit(c) || c == '.' && start+1 < len(l.code) && isDigit(l.code[start+1]):
+		l.scanNumber()
+		return true
+
+	case c == '/' && l.skipComment():
+		return true
+
+	case c == '"':
+		l.scanString()
+		return true
+	}
+
+	for _, tt := range operators[c] {
+		if text := tt.Literal(); strings.HasPrefix(l.code[start:], text) {
+			l.emit(tt, start+len(text))
 			return true
 		}
 	}
 
-	panic(fmt.Sprintf("Ошибка компиляции кода на позиции %d", l.pos)) // Пройтись дебагером
+	// Неизвестный символ: сообщаем и пропускаем его, чтобы найти остальные ошибки
+	_, size := utf8.DecodeRuneInString(l.code[l.pos:])
+	l.Diagnostics.Add(diagnostics.Errorf(
+		diagnostics.Span{Start: l.pos, End: l.pos + size},
+		diagnostics.CodeUnexpectedChar,
+		"неожиданный символ %q", l.code[l.pos:l.pos+size],
+	))
+	l.pos += size
+	return true
+}
+
+// scanNumber читает числовую константу: 0xFF, 0b1010, 0o17, 1_000_000 (INTEGER)
+// или 2.5, .5, 1e9, 2.5e-3 (DOUBLE) и сразу проверяет, что её значение разбирается
+func (l *Lexer) scanNumber() {
+	start := l.pos
+	end := start
+	isDouble := false
+
+	if prefixedIntBase(l.code[start:]) != 0 {
+		end += 2
+		for end < len(l.code) && (isHexDigit(l.code[end]) || l.code[end] == '_') {
+			end++
+		}
+	} else {
+		end = l.skipDigits(end)
+		if end+1 < len(l.code) && l.code[end] == '.' && isDigit(l.code[end+1]) {
+			isDouble = true
+			end = l.skipDigits(end + 1)
+		}
+		// Экспонента: e9, E+3, e-3
+		if end < len(l.code) && (l.code[end] == 'e' || l.code[end] == 'E') {
+			exp := end + 1
+			if exp < len(l.code) && (l.code[exp] == '+' || l.code[exp] == '-') {
+				exp++
+			}
+			if exp < len(l.code) && isDigit(l.code[exp]) {
+				isDouble = true
+				end = l.skipDigits(exp)
+			}
+		}
+	}
+
+	text := l.code[start:end]
+	span := diagnostics.Span{Start: start, End: end}
+	if isDouble {
+		if _, err := ParseFloatLiteral(text); err != nil {
+			l.Diagnostics.Add(diagnostics.Errorf(span, diagnostics.CodeMalformedNumber, "некорректное вещественное число %s", text))
+		}
+		l.emit(doubleType, end)
+		return
+	}
+
+	// Выход за диапазон int не ошибка записи: его проверяет парсер
+	if _, err := ParseIntLiteral(text); err != nil && !errors.Is(err, strconv.ErrRange) {
+		l.Diagnostics.Add(diagnostics.Errorf(span, diagnostics.CodeMalformedNumber, "некорректное целое число %s", text))
+	}
+	l.emit(integerType, end)
+}
+
+// skipDigits пропускает десятичные цифры и разделители '_' начиная с pos
+func (l *Lexer) skipDigits(pos int) int {
+	for pos < len(l.code) && (isDigit(l.code[pos]) || l.code[pos] == '_') {
+		pos++
+	}
+	return pos
+}
+
+// scanString читает строковую константу и декодирует escape-последовательности
+// \n, \t, \", \\ и \u{...} (кроме \u{0}). Неверная последовательность попадает
+// в диагностики и остаётся в значении как есть.
+func (l *Lexer) scanString() {
+	start := l.pos
+	var value strings.Builder
+	pos := start + 1
+	for pos < len(l.code) {
+		switch c := l.code[pos]; c {
+		case '"':
+			l.emit(stringType, pos+1)
+			l.Tokens[len(l.Tokens)-1].Value = value.String()
+			return
+		case '\\':
+			r, size, ok := decodeEscape(l.code[pos:])
+			switch {
+			case ok && r == 0:
+				// Строки попадают в LLVM как C-строки с завершающим нулём:
+				// всё после \u{0} скомпилированная программа бы потеряла
+				l.Diagnostics.Add(diagnostics.Errorf(
+					diagnostics.Span{Start: pos, End: pos + size},
+					diagnostics.CodeInvalidEscape,
+					"нулевой символ %q в строке не допускается", l.code[pos:pos+size],
+				))
+				value.WriteString(l.code[pos : pos+size])
+			case ok:
+				value.WriteRune(r)
+			default:
+				l.Diagnostics.Add(diagnostics.Errorf(
+					diagnostics.Span{Start: pos, End: pos + size},
+					diagnostics.CodeInvalidEscape,
+					"неверная escape-последовательность %q", l.code[pos:pos+size],
+				))
+				value.WriteString(l.code[pos : pos+size])
+			}
+			pos += size
+		default:
+			value.WriteByte(c)
+			pos++
+		}
+	}
+
+	l.Diagnostics.Add(diagnostics.Errorf(
+		diagnostics.Span{Start: start, End: len(l.code)},
+		diagnostics.CodeUnterminatedString,
+		"незакрытая строковая константа",
+	))
+	l.pos = len(l.code)
+}
+
+// decodeEscape разбирает escape-последовательность в начале s (s[0] == '\\').
+// Возвращает символ, длину последовательности в байтах и признак корректности.
+func decodeEscape(s string) (rune, int, bool) {
+	if len(s) < 2 {
+		return 0, len(s), false
+	}
+	switch s[1] {
+	case 'n':
+		return '\n', 2, true
+	case 't':
+		return '\t', 2, true
+	case '"':
+		return '"', 2, true
+	case '\\':
+		return '\\', 2, true
+	case 'u':
+		if len(s) < 3 || s[2] != '{' {
+			return 0, 2, false
+		}
+		end := 3
+		for end < len(s) && isHexDigit(s[end]) {
+			end++
+		}
+		if end == len(s) || s[end] != '}' {
+			return 0, end, false
+		}
+		code, err := strconv.ParseUint(s[3:end], 16, 32)
+		if end == 3 || end > 9 || err != nil || !utf8.ValidRune(rune(code)) {
+			return 0, end + 1, false
+		}
+		return rune(code), end + 1, true
+	default:
+		_, size := utf8.DecodeRuneInString(s[1:])
+		return 0, 1 + size, false
+	}
+}
+
+// emit добавляет токен типа tt, занимающий исходник от текущей позиции до end
+func (l *Lexer) emit(tt TokenType, end int) {
+	token := Token{TypeToken: tt, Text: l.code[l.pos:end], Pos: l.pos}
+	// Накопленные комментарии принадлежат следующему токену
+	if l.TrailingTrivia != nil {
+		token.LeadingTrivia = l.TrailingTrivia
+		l.TrailingTrivia = nil
+	}
+	l.Tokens = append(l.Tokens, token)
+	l.pos = end
+}
+
+// isIdentAt сообщает, продолжается ли идентификатор в позиции pos
+func (l *Lexer) isIdentAt(pos int) bool {
+	return pos < len(l.code) && (isLetter(l.code[pos]) || isDigit(l.code[pos]))
+}
+
+func isWhitespace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\t' || c == '\r'
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isHexDigit(c byte) bool {
+	return isDigit(c) || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
 }
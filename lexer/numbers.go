@@ -0,0 +1,82 @@
+package lexer
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ParseIntLiteral вычисляет значение токена INTEGER: десятичного (ведущие нули
+// не делают число восьмеричным), 0x/0b/0o и с разделителями '_'. Ведущий '-'
+// допускается для литерала, который парсер склеил с унарным минусом (-2147483648).
+// Значения вне диапазона int32 дают ошибку с strconv.ErrRange.
+func ParseIntLiteral(text string) (int64, error) {
+	sign := ""
+	unsigned := text
+	if strings.HasPrefix(text, "-") {
+		sign, unsigned = "-", text[1:]
+	}
+	base := prefixedIntBase(unsigned)
+	digits := unsigned
+	if base != 0 {
+		digits = unsigned[2:]
+	} else {
+		base = 10
+	}
+
+	if !validUnderscores(digits) {
+		return 0, &strconv.NumError{Func: "ParseIntLiteral", Num: text, Err: strconv.ErrSyntax}
+	}
+	value, err := strconv.ParseInt(sign+strings.ReplaceAll(digits, "_", ""), base, 64)
+	if err == nil && (value > math.MaxInt32 || value < math.MinInt32) {
+		err = strconv.ErrRange
+	}
+	if err != nil {
+		if numErr, ok := err.(*strconv.NumError); ok {
+			err = numErr.Err
+		}
+		return 0, &strconv.NumError{Func: "ParseIntLiteral", Num: text, Err: err}
+	}
+	return value, nil
+}
+
+// ParseFloatLiteral вычисляет значение токена DOUBLE: 2.5, .5, 1e9, 2.5e-3, 1_000.5
+func ParseFloatLiteral(text string) (float64, error) {
+	for _, part := range strings.FieldsFunc(text, func(r rune) bool {
+		return r == '.' || r == 'e' || r == 'E' || r == '+' || r == '-'
+	}) {
+		if !validUnderscores(part) {
+			return 0, &strconv.NumError{Func: "ParseFloatLiteral", Num: text, Err: strconv.ErrSyntax}
+		}
+	}
+	return strconv.ParseFloat(strings.ReplaceAll(text, "_", ""), 64)
+}
+
+// prefixedIntBase возвращает основание для 0x/0b/0o или 0, если префикса нет
+func prefixedIntBase(text string) int {
+	if len(text) < 2 || text[0] != '0' {
+		return 0
+	}
+	switch text[1] {
+	case 'x', 'X':
+		return 16
+	case 'b', 'B':
+		return 2
+	case 'o', 'O':
+		return 8
+	}
+	return 0
+}
+
+// validUnderscores: '_' допустим только между цифрами
+func validUnderscores(digits string) bool {
+	if digits == "" {
+		return false
+	}
+	for i := 0; i < len(digits); i++ {
+		if digits[i] == '_' && (i == 0 || i == len(digits)-1 || digits[i-1] == '_') {
+			return false
+		}
+	}
+	return true
+}
@@ -1,20 +1,80 @@
 package lexer
 
+import (
+	"compiler_project/diagnostics"
+	"strings"
+)
+
 type Token struct {
 	TypeToken TokenType
 	Text      string
 	Pos       int
+
+	// Value — декодированное значение литерала: для STRING это текст без кавычек
+	// с обработанными escape-последовательностями. Text всегда хранит исходник как есть.
+	Value string
+
+	// LeadingTrivia — комментарии перед токеном; заполняется, только если Lexer.KeepTrivia
+	LeadingTrivia []Trivia
 }
 
 func NewToken(typeToken TokenType, text string, pos int) *Token {
 	return &Token{TypeToken: typeToken, Text: text, Pos: pos}
 }
 
+// Span возвращает участок исходника, занимаемый токеном
+func (t Token) Span() diagnostics.Span {
+	return diagnostics.Span{Start: t.Pos, End: t.Pos + len(t.Text)}
+}
+
+type TriviaKind int
+
+const (
+	LineComment  TriviaKind = iota // // до конца строки
+	BlockComment                   // /* ... */, может быть вложенным
+)
+
+// Trivia — фрагмент исходника, который не влияет на разбор, но нужен форматтеру
+// и генератору документации, чтобы вернуть комментарии к узлам AST
+type Trivia struct {
+	Kind TriviaKind
+	Text string
+	Pos  int
+}
+
 type TokenType struct {
 	Name  string
 	Regex string
 }
 
+// symbolicOperators — символьные операторы, у которых есть словесная форма
+var symbolicOperators = map[string]string{
+	"EQ":   "equal",
+	"NEQ":  "non-equal",
+	"LT":   "less",
+	"GT":   "more",
+	"LE":   "less-or-equal",
+	"GE":   "more-or-equal",
+	"LAND": "and",
+	"LOR":  "or",
+	"LNOT": "not",
+}
+
+// Operator возвращает каноническое имя операции: у сравнений и логики это словесная
+// форма (== и equal дают "equal", && и and — "and"), у остальных — сам символ ("+").
+// По нему операции различают checker, интерпретатор, TAC и llvmgen.
+func (t TokenType) Operator() string {
+	if word, ok := symbolicOperators[t.Name]; ok {
+		return word
+	}
+	return t.Literal()
+}
+
+// Literal возвращает текст токена фиксированного вида (шаблон без экранирования: `\+` → "+")
+func (t TokenType) Literal() string {
+	return strings.ReplaceAll(t.Regex, `\`, "")
+}
+
 func NewTokenType(name string, regex string) *TokenType {
 	return &TokenType{Name: name, Regex: regex}
 }
@@ -42,6 +102,8 @@ var TokenTypeList = &map[string]TokenType{
 	"PLUS":     *NewTokenType("PLUS", "\\+"),
 	"MULTIPLY": *NewTokenType("MULTIPLY", "\\*"),
 	"DIVIDE":   *NewTokenType("DIVIDE", "/"),
+	"MODULO":   *NewTokenType("MODULO", "%"),
+	"POWER":    *NewTokenType("POWER", `\*\*`),
 	"LPAREN":   *NewTokenType("LPAREN", "\\("),
 	"RPAREN":   *NewTokenType("RPAREN", "\\)"),
 	"LBRACE":   *NewTokenType("LBRACE", "{"),
@@ -52,11 +114,43 @@ var TokenTypeList = &map[string]TokenType{
 	"ELSE":     *NewTokenType("else", "else"),
 	"EQUAL":    *NewTokenType("EQUAL", "equal"),
 	"WHILE":    *NewTokenType("while", "while"),
+	"RETURN":   *NewTokenType("return", "return"),
 	"NONEQUAL": *NewTokenType("NONEQUAL", "non-equal"),
 	"MORE":     *NewTokenType("MORE", "more"),
 	"LESS":     *NewTokenType("LESS", "less"),
 	"AND":      *NewTokenType("AND", "and"),
 	"OR":       *NewTokenType("OR", "or"),
+	"NOT":      *NewTokenType("NOT", "not"),
+
+	"MOREOREQUAL": *NewTokenType("MOREOREQUAL", "more-or-equal"),
+	"LESSOREQUAL": *NewTokenType("LESSOREQUAL", "less-or-equal"),
+
+	// Символьные формы тех же операций
+	"EQ":   *NewTokenType("EQ", "=="),
+	"NEQ":  *NewTokenType("NEQ", "!="),
+	"LT":   *NewTokenType("LT", "<"),
+	"GT":   *NewTokenType("GT", ">"),
+	"LE":   *NewTokenType("LE", "<="),
+	"GE":   *NewTokenType("GE", ">="),
+	"LAND": *NewTokenType("LAND", "&&"),
+	"LOR":  *NewTokenType("LOR", `\|\|`),
+	"LNOT": *NewTokenType("LNOT", "!"),
+
+	// Побитовые операции
+	"BITAND": *NewTokenType("BITAND", "&"),
+	"BITOR":  *NewTokenType("BITOR", `\|`),
+	"BITXOR": *NewTokenType("BITXOR", `\^`),
+	"SHL":    *NewTokenType("SHL", "<<"),
+	"SHR":    *NewTokenType("SHR", ">>"),
+
+	// Составное присваивание, инкремент и декремент
+	"PLUSASSIGN":     *NewTokenType("PLUSASSIGN", `\+=`),
+	"MINUSASSIGN":    *NewTokenType("MINUSASSIGN", "-="),
+	"MULTIPLYASSIGN": *NewTokenType("MULTIPLYASSIGN", `\*=`),
+	"DIVIDEASSIGN":   *NewTokenType("DIVIDEASSIGN", "/="),
+	"MODULOASSIGN":   *NewTokenType("MODULOASSIGN", "%="),
+	"INCREMENT":      *NewTokenType("INCREMENT", `\+\+`),
+	"DECREMENT":      *NewTokenType("DECREMENT", "--"),
 
 	"SEMICOLON":  *NewTokenType("SEMICOLON", ";"),
 	"WHITESPACE": *NewTokenType("WHITESPACE", "[ \n\t\r]+"),
@@ -69,6 +163,7 @@ var TokenTypesOrdered = []TokenType{
 	*NewTokenType("else", "else"),
 	*NewTokenType("while", "while"),
 	*NewTokenType("func", "func"),
+	*NewTokenType("return", "return"),
 	//*NewTokenType("VAR", "var"),
 	*NewTokenType("int", "int"),
 	*NewTokenType("double", "double"),
@@ -81,10 +176,13 @@ var TokenTypesOrdered = []TokenType{
 	// Логические операторы
 	*NewTokenType("EQUAL", "equal"),
 	*NewTokenType("NONEQUAL", "non-equal"),
+	*NewTokenType("MOREOREQUAL", "more-or-equal"),
+	*NewTokenType("LESSOREQUAL", "less-or-equal"),
 	*NewTokenType("MORE", "more"),
 	*NewTokenType("LESS", "less"),
 	*NewTokenType("AND", "and"),
 	*NewTokenType("OR", "or"),
+	*NewTokenType("NOT", "not"),
 
 	// Литералы
 	*NewTokenType("VARIABLE", `[a-zA-Z_][a-zA-Z0-9_]*`), // важно ставить после ключевых слов
@@ -92,12 +190,39 @@ var TokenTypesOrdered = []TokenType{
 	*NewTokenType("STRING", "\"[^\"]*\""),
 	*NewTokenType("INTEGER", `\d+`),
 
+	// Операторы сравнения и логики (двухсимвольные раньше односимвольных)
+	*NewTokenType("EQ", "=="),
+	*NewTokenType("NEQ", "!="),
+	*NewTokenType("SHL", "<<"),
+	*NewTokenType("SHR", ">>"),
+	*NewTokenType("LE", "<="),
+	*NewTokenType("GE", ">="),
+	*NewTokenType("LT", "<"),
+	*NewTokenType("GT", ">"),
+	*NewTokenType("LAND", "&&"),
+	*NewTokenType("LOR", `\|\|`),
+	*NewTokenType("LNOT", "!"),
+	*NewTokenType("BITAND", "&"),
+	*NewTokenType("BITOR", `\|`),
+	*NewTokenType("BITXOR", `\^`),
+	*NewTokenType("POWER", `\*\*`),
+
+	// Составное присваивание, инкремент и декремент (раньше односимвольных + - * / %)
+	*NewTokenType("PLUSASSIGN", `\+=`),
+	*NewTokenType("MINUSASSIGN", "-="),
+	*NewTokenType("MULTIPLYASSIGN", `\*=`),
+	*NewTokenType("DIVIDEASSIGN", "/="),
+	*NewTokenType("MODULOASSIGN", "%="),
+	*NewTokenType("INCREMENT", `\+\+`),
+	*NewTokenType("DECREMENT", "--"),
+
 	// Арифметические операторы
 	*NewTokenType("ASSIGN", "="),
 	*NewTokenType("PLUS", `\+`),
 	*NewTokenType("MINUS", "-"),
 	*NewTokenType("MULTIPLY", "\\*"),
 	*NewTokenType("DIVIDE", "/"),
+	*NewTokenType("MODULO", "%"),
 
 	// Скобки и разделители
 	*NewTokenType("LPAREN", `\(`),
@@ -1 +1,103 @@
 package llvmgen
+
+import (
+	"compiler_project/diagnostics"
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/constant"
+	"github.com/llir/llvm/ir/enum"
+	"github.com/llir/llvm/ir/types"
+	"github.com/llir/llvm/ir/value"
+)
+
+// ZeroDivisionStatus — код завершения программы при целом делении на ноль.
+// Он совпадает с кодом ошибки выполнения драйвера, поэтому --emit=run
+// и собранная программа завершаются одинаково.
+const ZeroDivisionStatus = 4
+
+// exit завершает программу с кодом status. В main это переход в общий эпилог,
+// который возвращает код из main; в остальных функциях — вызов exit из libc.
+func (b *LLVMBuilder) exit(status value.Value) {
+	if b.funcContext == b.mainContext {
+		if b.epilogue == nil {
+			b.epilogue = b.fn.NewBlock("epilogue")
+		}
+		b.exitStatuses = append(b.exitStatuses, ir.NewIncoming(status, b.block))
+		b.block.NewBr(b.epilogue)
+	} else {
+		b.waitForKey(b.block)
+		exitFn := b.ensureDeclared("exit", types.Void, types.I32)
+		b.block.NewCall(exitFn, status)
+		b.block.NewUnreachable()
+	}
+	// Код после exit недостижим; как и после return, пишем его в отдельный блок
+	b.block = b.fn.NewBlock(b.uniqueLabel("after_exit"))
+}
+
+// finishMain завершает main: обычный конец программы возвращает 0,
+// вызовы exit сходятся в эпилог и возвращают свой код
+func (b *LLVMBuilder) finishMain() {
+	if b.epilogue == nil {
+		if !isTerminated(b.block) {
+			b.waitForKey(b.block)
+			b.block.NewRet(constant.NewInt(types.I32, 0))
+		}
+		return
+	}
+
+	if !isTerminated(b.block) {
+		b.exitStatuses = append(b.exitStatuses, ir.NewIncoming(constant.NewInt(types.I32, 0), b.block))
+		b.block.NewBr(b.epilogue)
+	}
+	status := b.epilogue.NewPhi(b.exitStatuses...)
+	b.waitForKey(b.epilogue)
+	b.epilogue.NewRet(status)
+}
+
+// waitForKey ждёт нажатия Enter перед выходом, если это включено в Options
+func (b *LLVMBuilder) waitForKey(block *ir.Block) {
+	if !b.opts.WaitForKey {
+		return
+	}
+	getchar := b.ensureDeclared("getchar", types.I32)
+	block.NewCall(getchar)
+}
+
+// checkDivisor проверяет делитель перед sdiv/srem: деление на ноль в LLVM —
+// неопределённое поведение, а программа, как и интерпретатор, должна сообщить
+// об ошибке. Делитель-константу, отличную от нуля, проверять незачем.
+func (b *LLVMBuilder) checkDivisor(divisor value.Value) {
+	if c, ok := divisor.(*constant.Int); ok && c.X.Sign() != 0 {
+		return
+	}
+	fail := b.fn.NewBlock(b.uniqueLabel("div_zero"))
+	fail.NewCall(b.ensureZeroDivision())
+	fail.NewUnreachable()
+	next := b.fn.NewBlock(b.uniqueLabel("div_ok"))
+	isZero := b.block.NewICmp(enum.IPredEQ, divisor, constant.NewInt(types.I32, 0))
+	b.block.NewCondBr(isZero, fail, next)
+	b.block = next
+}
+
+// ensureZeroDivision определяет zero.division(): печатает в stderr ошибку R0005
+// и завершает программу с кодом ZeroDivisionStatus
+func (b *LLVMBuilder) ensureZeroDivision() *ir.Func {
+	const name = "zero.division"
+	if fn, ok := b.helpers[name]; ok {
+		return fn
+	}
+	fn := b.mod.NewFunc(name, types.Void)
+	fn.Linkage = enum.LinkageInternal
+	b.helpers[name] = fn
+
+	dprintf := b.ensureDeclared("dprintf", types.I32, types.I32, types.I8Ptr)
+	dprintf.Sig.Variadic = true
+	message := "error[" + diagnostics.CodeZeroDivision + "]: целочисленное деление на ноль\n"
+
+	entry := fn.NewBlock("entry")
+	entry.NewCall(dprintf, constant.NewInt(types.I32, 2), b.ensureGlobalString(message, ".err.zero.division"))
+	b.waitForKey(entry)
+	exitFn := b.ensureDeclared("exit", types.Void, types.I32)
+	entry.NewCall(exitFn, constant.NewInt(types.I32, ZeroDivisionStatus))
+	entry.NewUnreachable()
+	return fn
+}
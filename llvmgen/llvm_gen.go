@@ -1,6 +1,8 @@
 package llvmgen
 
 import (
+	"compiler_project/diagnostics"
+	"compiler_project/lexer"
 	"compiler_project/tac"
 	"fmt"
 	"github.com/llir/llvm/ir"
@@ -9,158 +11,408 @@ import (
 	"github.com/llir/llvm/ir/types"
 	"github.com/llir/llvm/ir/value"
 	"strconv"
+	"strings"
 )
 
+// Options настраивает генерацию модуля
+type Options struct {
+	// WaitForKey добавляет перед выходом из программы ожидание нажатия Enter
+	// (getchar), чтобы консольное окно не закрывалось сразу
+	WaitForKey bool
+
+	// Target — триплет целевой платформы (см. Targets); пустой — DefaultTarget
+	Target string
+
+	// SourceFilename записывается в модуль как source_filename
+	SourceFilename string
+}
+
 type LLVMBuilder struct {
-	mod           *ir.Module
-	fnMain        *ir.Func
-	block         *ir.Block
-	vars          map[string]*ir.InstAlloca
-	printf        *ir.Func
-	namedValues   map[string]value.Value
-	globalStrings map[string]*ir.Global
-	varsTypes     map[string]types.Type
+	*funcContext // функция, код которой генерируется сейчас
+
+	opts           Options
+	mod            *ir.Module
+	fnMain         *ir.Func
+	mainContext    *funcContext
+	functions      map[string]*ir.Func   // пользовательские функции по имени из TAC
+	globals        map[string]*ir.Global // переменные верхнего уровня по имени из TAC
+	printf         *ir.Func
+	globalStrings  map[string]*ir.Global
+	stringLiterals map[string]constant.Constant
+	helpers        map[string]*ir.Func // объявленные интринсики и вспомогательные функции
+	epilogue       *ir.Block           // общий выход из main, если программа вызывает exit
+	exitStatuses   []*ir.Incoming      // коды возврата, с которыми переходят в epilogue
+	Diagnostics    diagnostics.List
+}
+
+// funcContext — состояние генерации одной функции: у каждой свои блоки,
+// переменные, временные значения и метки
+type funcContext struct {
+	fn          *ir.Func
+	block       *ir.Block
+	vars        map[string]*ir.InstAlloca
+	namedValues map[string]value.Value
+	labelBlocks map[string]*ir.Block
+	params      []value.Value // значения из param, ещё не забранные call
+	allocaCount int           // сколько alloca уже стоит в начале entry-блока
+}
+
+func newFuncContext(fn *ir.Func) *funcContext {
+	return &funcContext{
+		fn:          fn,
+		block:       fn.NewBlock("entry"),
+		vars:        map[string]*ir.InstAlloca{},
+		namedValues: make(map[string]value.Value),
+		labelBlocks: make(map[string]*ir.Block),
+	}
+}
+
+// reservedNames — внешние функции, которые модуль вызывает сам; пользовательская
+// функция с таким именем конфликтовала бы с ними при компоновке. Собственные
+// глобальные имена модуля (.fmt.int, .str.N, pow.i32, g.x) и имена параметров
+// (p.x) содержат точку, которой нет в идентификаторах языка, поэтому не совпадают
+// ни с пользовательскими функциями, ни с метками блоков (entry, L1, continue_N).
+var reservedNames = map[string]bool{
+	"main":    true,
+	"printf":  true,
+	"strcmp":  true,
+	"exit":    true,
+	"getchar": true,
+	"dprintf": true,
 }
 
-func NewLLVMBuilder() *LLVMBuilder {
+// paramPrefix начинает имена параметров в IR: без него параметр entry или L1
+// совпал бы с меткой блока в той же функции
+const paramPrefix = "p."
+
+func NewLLVMBuilder(opts Options) *LLVMBuilder {
 	mod := ir.NewModule()
+	mod.SourceFilename = opts.SourceFilename
+	target, targetOK := LookupTarget(opts.Target)
+	mod.TargetTriple = target.Triple
+	mod.DataLayout = target.DataLayout
 	mainFn := mod.NewFunc("main", types.I32)
-	entry := mainFn.NewBlock("entry")
-
-	return &LLVMBuilder{
-		mod:           mod,
-		fnMain:        mainFn,
-		block:         entry,
-		vars:          map[string]*ir.InstAlloca{},
-		namedValues:   make(map[string]value.Value),
-		globalStrings: make(map[string]*ir.Global),
+	mainContext := newFuncContext(mainFn)
+
+	b := &LLVMBuilder{
+		funcContext:    mainContext,
+		opts:           opts,
+		mod:            mod,
+		fnMain:         mainFn,
+		mainContext:    mainContext,
+		functions:      make(map[string]*ir.Func),
+		globals:        make(map[string]*ir.Global),
+		helpers:        make(map[string]*ir.Func),
+		globalStrings:  make(map[string]*ir.Global),
+		stringLiterals: make(map[string]constant.Constant),
 	}
+	if !targetOK {
+		b.errorf(diagnostics.CodeUnknownTarget, "неизвестная целевая платформа %s; поддерживаются: %s", opts.Target, strings.Join(Targets(), ", "))
+	}
+	return b
 }
 
-func (b *LLVMBuilder) GenerateFromTAC(instructions []tac.TACInstruction) {
-	labelBlocks := make(map[string]*ir.Block)
-
+// declareFunctions — первый проход: создаёт ir.Func для каждой пары func/formal,
+// чтобы вызовы находили определение независимо от порядка в программе
+func (b *LLVMBuilder) declareFunctions(instructions []tac.TACInstruction) {
 	for idx := 0; idx < len(instructions); idx++ {
 		instr := instructions[idx]
+		if instr.Op != "func" {
+			continue
+		}
+		if reservedNames[instr.Res] {
+			b.errorf(diagnostics.CodeReservedName, "имя функции %s зарезервировано", instr.Res)
+		}
+		if _, ok := b.functions[instr.Res]; ok {
+			b.errorf(diagnostics.CodeMalformedTAC, "функция %s определена дважды", instr.Res)
+			continue
+		}
+
+		retType := types.Type(types.Void)
+		if instr.Arg1 != "void" {
+			t, ok := llvmType(instr.Arg1)
+			if !ok {
+				b.errorf(diagnostics.CodeUnsupportedType, "неподдерживаемый тип результата функции %s: %s", instr.Res, instr.Arg1)
+				t = types.I32
+			}
+			retType = t
+		}
+
+		var params []*ir.Param
+		for ; idx+1 < len(instructions) && instructions[idx+1].Op == "formal"; idx++ {
+			formal := instructions[idx+1]
+			t, ok := llvmType(formal.Arg1)
+			if !ok {
+				b.errorf(diagnostics.CodeUnsupportedType, "неподдерживаемый тип параметра %s: %s", formal.Res, formal.Arg1)
+				t = types.I32
+			}
+			params = append(params, ir.NewParam(paramPrefix+formal.Res, t))
+		}
 
+		b.functions[instr.Res] = b.mod.NewFunc(instr.Res, retType, params...)
+	}
+}
+
+func (b *LLVMBuilder) GenerateFromTAC(instructions []tac.TACInstruction) {
+	b.declareFunctions(instructions)
+
+	for _, instr := range instructions {
 		switch instr.Op {
+		case "func":
+			fn := b.functions[instr.Res]
+			if fn == nil || b.funcContext != b.mainContext {
+				b.errorf(diagnostics.CodeMalformedTAC, "функция %s объявлена не на верхнем уровне", instr.Res)
+				continue
+			}
+			// Параметры копируются в alloca, чтобы их можно было переприсваивать
+			b.funcContext = newFuncContext(fn)
+			for _, param := range fn.Params {
+				ptr := b.declareVar(strings.TrimPrefix(param.Name(), paramPrefix), param.Type())
+				b.block.NewStore(param, ptr)
+			}
+
+		case "formal":
+			// Параметры уже созданы в declareFunctions
+
+		case "endfunc":
+			b.finishFunction()
+			b.funcContext = b.mainContext
+
+		case "return":
+			if b.fn.Sig.RetType.Equal(types.Void) {
+				b.block.NewRet(nil)
+			} else {
+				b.block.NewRet(b.getValue(instr.Arg1))
+			}
+			// Код после return недостижим; как и после goto, пишем его в отдельный блок
+			b.block = b.fn.NewBlock(b.uniqueLabel("after_return"))
+
+		case "decl":
+			varType, ok := llvmType(instr.Arg1)
+			if !ok {
+				b.errorf(diagnostics.CodeUnsupportedType, "неподдерживаемый тип переменной %s: %s", instr.Res, instr.Arg1)
+				continue
+			}
+			b.declareVar(instr.Res, varType)
+
+		case "global":
+			varType, ok := llvmType(instr.Arg1)
+			if !ok {
+				b.errorf(diagnostics.CodeUnsupportedType, "неподдерживаемый тип переменной %s: %s", instr.Res, instr.Arg1)
+				continue
+			}
+			b.declareGlobal(instr.Res, varType)
+
 		case "=":
 			val := b.getValue(instr.Arg1)
-			ptr := b.ensureVar(instr.Res)
-
-			// Если типы не совпадают — возможно, нужно привести val
-			// или просто сделать store с правильным типом
+			// Присваивание пишет в память, выделенную decl или global; новую не заводим
+			ptr, elemType, ok := b.lookupVar(instr.Res)
+			if !ok {
+				b.errorf(diagnostics.CodeMalformedTAC, "присваивание необъявленной переменной %s", instr.Res)
+				continue
+			}
+			if !val.Type().Equal(elemType) {
+				b.errorf(diagnostics.CodeUnsupportedType, "нельзя записать %s в переменную %s типа %s", val.Type(), instr.Res, elemType)
+				continue
+			}
 			b.block.NewStore(val, ptr)
 
-		case "+", "-", "*", "/":
+		case "+", "-", "*", "/", "%":
 			l := b.getValue(instr.Arg1)
 			r := b.getValue(instr.Arg2)
 			var result value.Value
-			switch instr.Op {
-			case "+":
-				result = b.block.NewAdd(l, r)
-			case "-":
-				result = b.block.NewSub(l, r)
-			case "*":
-				result = b.block.NewMul(l, r)
-			case "/":
-				result = b.block.NewSDiv(l, r)
+			if isFloatOp(instr, l) {
+				switch instr.Op {
+				case "+":
+					result = b.block.NewFAdd(l, r)
+				case "-":
+					result = b.block.NewFSub(l, r)
+				case "*":
+					result = b.block.NewFMul(l, r)
+				case "/":
+					result = b.block.NewFDiv(l, r)
+				case "%":
+					result = b.block.NewFRem(l, r)
+				}
+			} else {
+				switch instr.Op {
+				case "+":
+					result = b.block.NewAdd(l, r)
+				case "-":
+					result = b.block.NewSub(l, r)
+				case "*":
+					result = b.block.NewMul(l, r)
+				case "/":
+					b.checkDivisor(r)
+					result = b.block.NewSDiv(l, r)
+				case "%":
+					b.checkDivisor(r)
+					result = b.block.NewSRem(l, r)
+				}
 			}
-			ptr := b.ensureVar(instr.Res)
-			b.block.NewStore(result, ptr)
+			// Временная переменная используется сразу же, поэтому память под неё не нужна
+			b.setResult(instr, result)
 
 		case "show":
-			val := b.getValue(instr.Arg1)
-			printf := b.ensurePrintf()
-			formatStr := b.ensureGlobalString("%d\n", "fmt")
-			b.block.NewCall(printf, formatStr, val)
+			b.show(b.getValue(instr.Arg1))
 
-		case "showstr":
-			formatStr := b.ensureGlobalString("%s\n", "fmt_str")
-			str := b.ensureGlobalString(instr.Arg1, b.uniqueGlobalName("str"))
-			printf := b.ensurePrintf()
-			b.block.NewCall(printf, formatStr, str)
+		case "exit":
+			b.exit(b.getValue(instr.Arg1))
 
-		case "less", "more", "equal":
+		case "less", "more", "equal", "non-equal", "less-or-equal", "more-or-equal":
 			l := b.getValue(instr.Arg1)
 			r := b.getValue(instr.Arg2)
 			var cmp value.Value
+			switch {
+			case types.IsFloat(l.Type()):
+				cmp = b.block.NewFCmp(floatPredicates[instr.Op], l, r)
+			case l.Type().Equal(types.I8Ptr):
+				// Строки сравниваются по содержимому: знак strcmp сравнивается с нулём
+				strcmp := b.ensureDeclared("strcmp", types.I32, types.I8Ptr, types.I8Ptr)
+				order := b.block.NewCall(strcmp, l, r)
+				cmp = b.block.NewICmp(intPredicates[instr.Op], order, constant.NewInt(types.I32, 0))
+			default:
+				cmp = b.block.NewICmp(intPredicates[instr.Op], l, r)
+			}
+			b.setResult(instr, cmp)
+
+		case "&", "|", "^", "<<", ">>":
+			l := b.getValue(instr.Arg1)
+			r := b.getValue(instr.Arg2)
+			var result value.Value
 			switch instr.Op {
-			case "less":
-				cmp = b.block.NewICmp(enum.IPredSLT, l, r)
-			case "more":
-				cmp = b.block.NewICmp(enum.IPredSGT, l, r)
-			case "equal":
-				cmp = b.block.NewICmp(enum.IPredEQ, l, r)
+			case "&":
+				result = b.block.NewAnd(l, r)
+			case "|":
+				result = b.block.NewOr(l, r)
+			case "^":
+				result = b.block.NewXor(l, r)
+			case "<<", ">>":
+				// Сдвиг на 32 и больше в LLVM даёт poison, поэтому берём величину по модулю 32
+				amount := b.block.NewAnd(r, constant.NewInt(types.I32, 31))
+				if instr.Op == "<<" {
+					result = b.block.NewShl(l, amount)
+				} else {
+					result = b.block.NewAShr(l, amount)
+				}
+			}
+			b.setResult(instr, result)
+
+		case "**":
+			l := b.getValue(instr.Arg1)
+			r := b.getValue(instr.Arg2)
+			var fn *ir.Func
+			switch {
+			case !isFloatOp(instr, l):
+				fn = b.ensureIntPow()
+			case types.IsFloat(r.Type()):
+				fn = b.ensureDeclared("llvm.pow.f64", types.Double, types.Double, types.Double)
+			default:
+				fn = b.ensureDeclared("llvm.powi.f64.i32", types.Double, types.Double, types.I32)
+			}
+			b.setResult(instr, b.block.NewCall(fn, l, r))
+
+		case "neg":
+			operand := b.getValue(instr.Arg1)
+			if isFloatOp(instr, operand) {
+				b.setResult(instr, b.block.NewFNeg(operand))
+			} else {
+				b.setResult(instr, b.block.NewSub(constant.NewInt(types.I32, 0), operand))
+			}
+
+		case "not":
+			operand := b.getValue(instr.Arg1)
+			b.setResult(instr, b.block.NewXor(operand, constant.NewInt(types.I1, 1)))
+
+		case "and", "or":
+			l := b.getValue(instr.Arg1)
+			r := b.getValue(instr.Arg2)
+			if instr.Op == "and" {
+				b.setResult(instr, b.block.NewAnd(l, r))
+			} else {
+				b.setResult(instr, b.block.NewOr(l, r))
 			}
-			b.namedValues[instr.Res] = cmp
 
 		case "iffalse":
 			cond := b.getValue(instr.Arg1) // cond — это i1 (например, результат icmp)
 
 			// Блок, если условие ложно (перейти на Res)
-			falseBlock := labelBlocks[instr.Res]
+			falseBlock := b.labelBlocks[instr.Res]
 			if falseBlock == nil {
-				falseBlock = b.fnMain.NewBlock(instr.Res)
-				labelBlocks[instr.Res] = falseBlock
+				falseBlock = b.fn.NewBlock(instr.Res)
+				b.labelBlocks[instr.Res] = falseBlock
 			}
 
 			// Продолжение, если условие истинно (continue блок)
-			trueBlock := b.fnMain.NewBlock(b.uniqueLabel("continue"))
+			trueBlock := b.fn.NewBlock(b.uniqueLabel("continue"))
 			b.block.NewCondBr(cond, trueBlock, falseBlock)
 
 			// Продолжаем генерацию в trueBlock
 			b.block = trueBlock
 
 		case "goto":
-			targetBlock := labelBlocks[instr.Res]
+			targetBlock := b.labelBlocks[instr.Res]
 			if targetBlock == nil {
-				targetBlock = b.fnMain.NewBlock(instr.Res)
-				labelBlocks[instr.Res] = targetBlock
+				targetBlock = b.fn.NewBlock(instr.Res)
+				b.labelBlocks[instr.Res] = targetBlock
 			}
 			// Завершаем текущий блок переходом, если нет терминатора
-			lastInsts := b.block.Insts
-			if len(lastInsts) == 0 || (!isTerminator(lastInsts[len(lastInsts)-1])) {
+			if !isTerminated(b.block) {
 				b.block.NewBr(targetBlock)
 			}
-			// Переключаемся на целевой блок
-			b.block = targetBlock
+			// Код после безусловного перехода недостижим до следующей метки;
+			// пишем его в отдельный блок, а не в целевой
+			b.block = b.fn.NewBlock(b.uniqueLabel("after_goto"))
 
 		case "label":
-			block := labelBlocks[instr.Res]
+			block := b.labelBlocks[instr.Res]
 			if block == nil {
-				block = b.fnMain.NewBlock(instr.Res)
-				labelBlocks[instr.Res] = block
+				block = b.fn.NewBlock(instr.Res)
+				b.labelBlocks[instr.Res] = block
 			}
 			// Если текущий блок не закончен, завершаем его переходом на этот
-			lastInsts := b.block.Insts
-			if len(lastInsts) > 0 && !isTerminator(lastInsts[len(lastInsts)-1]) {
+			if !isTerminated(b.block) {
 				b.block.NewBr(block)
 			}
 			b.block = block
 
+		case "param":
+			val := b.getValue(instr.Arg1)
+			b.checkType(instr, val)
+			b.params = append(b.params, val)
+
 		case "call":
-			fn := b.mod.NewFunc(instr.Arg1, types.I32)
-			argsCount, _ := strconv.Atoi(instr.Arg2)
-			args := make([]value.Value, argsCount)
-			for i := 0; i < argsCount; i++ {
-				idx++
-				argInstr := instructions[idx]
-				args[i] = b.getValue(argInstr.Arg1)
+			// Вызов забирает последние n значений, переданных через param
+			argsCount, err := strconv.Atoi(instr.Arg2)
+			if err != nil || argsCount < 0 || argsCount > len(b.params) {
+				b.errorf(diagnostics.CodeMalformedTAC, "вызову %s не хватает param: ожидается %s", instr.Arg1, instr.Arg2)
+				continue
+			}
+			args := append([]value.Value(nil), b.params[len(b.params)-argsCount:]...)
+			b.params = b.params[:len(b.params)-argsCount]
+			fn := b.functions[instr.Arg1]
+			if fn == nil {
+				b.errorf(diagnostics.CodeUnknownValue, "неизвестная функция %s", instr.Arg1)
+				continue
+			}
+			if len(args) != len(fn.Params) {
+				b.errorf(diagnostics.CodeMalformedTAC, "функция %s ожидает %d аргументов, передано %d", instr.Arg1, len(fn.Params), len(args))
+				continue
 			}
 			result := b.block.NewCall(fn, args...)
-			ptr := b.ensureVar(instr.Res)
-			b.block.NewStore(result, ptr)
+			if !fn.Sig.RetType.Equal(types.Void) {
+				b.setResult(instr, result)
+			}
 
 		case "while_start":
 			// Метка начала цикла
-			startBlock := b.fnMain.NewBlock(instr.Res + "_start")
-			endBlock := b.fnMain.NewBlock(instr.Res + "_end")
-			labelBlocks[instr.Res+"_start"] = startBlock
-			labelBlocks[instr.Res+"_end"] = endBlock
+			startBlock := b.fn.NewBlock(instr.Res + "_start")
+			endBlock := b.fn.NewBlock(instr.Res + "_end")
+			b.labelBlocks[instr.Res+"_start"] = startBlock
+			b.labelBlocks[instr.Res+"_end"] = endBlock
 
 			// Переход из текущего блока в начало цикла
-			if len(b.block.Insts) == 0 || !isTerminator(b.block.Insts[len(b.block.Insts)-1]) {
+			if !isTerminated(b.block) {
 				b.block.NewBr(startBlock)
 			}
 			b.block = startBlock
@@ -171,13 +423,13 @@ func (b *LLVMBuilder) GenerateFromTAC(instructions []tac.TACInstruction) {
 			zero := constant.NewInt(types.I32, 0)
 			condVal := b.block.NewICmp(enum.IPredNE, cond, zero)
 
-			bodyBlock := b.fnMain.NewBlock(instr.Res + "_body")
-			endBlock := labelBlocks[instr.Res+"_end"]
+			bodyBlock := b.fn.NewBlock(instr.Res + "_body")
+			endBlock := b.labelBlocks[instr.Res+"_end"]
 			if endBlock == nil {
-				endBlock = b.fnMain.NewBlock(instr.Res + "_end")
-				labelBlocks[instr.Res+"_end"] = endBlock
+				endBlock = b.fn.NewBlock(instr.Res + "_end")
+				b.labelBlocks[instr.Res+"_end"] = endBlock
 			}
-			labelBlocks[instr.Res+"_body"] = bodyBlock
+			b.labelBlocks[instr.Res+"_body"] = bodyBlock
 
 			b.block.NewCondBr(condVal, bodyBlock, endBlock)
 
@@ -185,59 +437,167 @@ func (b *LLVMBuilder) GenerateFromTAC(instructions []tac.TACInstruction) {
 
 		case "while_end":
 			// После тела цикла — переход к началу для проверки условия
-			startBlock := labelBlocks[instr.Res+"_start"]
-			endBlock := labelBlocks[instr.Res+"_end"]
+			startBlock := b.labelBlocks[instr.Res+"_start"]
+			endBlock := b.labelBlocks[instr.Res+"_end"]
 			if startBlock == nil || endBlock == nil {
-				panic("не найдены блоки while для " + instr.Res)
+				b.errorf(diagnostics.CodeMalformedTAC, "не найдены блоки while для %s", instr.Res)
+				continue
 			}
 
-			if len(b.block.Insts) == 0 || !isTerminator(b.block.Insts[len(b.block.Insts)-1]) {
+			if !isTerminated(b.block) {
 				b.block.NewBr(startBlock)
 			}
 			b.block = endBlock
 		}
 	}
 
-	// В конце ставим вызов system("pause") и ret
-	pauseStr := b.ensurePauseString()
-	pausePtr := b.block.NewGetElementPtr(
-		pauseStr.ContentType,
-		pauseStr,
-		constant.NewInt(types.I32, 0),
-		constant.NewInt(types.I32, 0),
-	)
-
-	systemFn := b.ensureSystemFunc()
-	b.block.NewCall(systemFn, pausePtr)
-
-	// Завершаем последний блок
-	if len(b.block.Insts) == 0 || !isTerminator(b.block.Insts[len(b.block.Insts)-1]) {
-		b.block.NewRet(constant.NewInt(types.I32, 0))
+	if b.funcContext != b.mainContext {
+		b.errorf(diagnostics.CodeMalformedTAC, "нет endfunc для функции %s", b.fn.Name())
+		b.funcContext = b.mainContext
 	}
+
+	b.finishMain()
 }
 
-// Вспомогательная функция для проверки терминатора
-func isTerminator(inst ir.Instruction) bool {
-	_, ok := inst.(ir.Terminator)
-	return ok
+// finishFunction завершает последний блок функции. Checker гарантирует return
+// на всех путях, поэтому в функции с результатом сюда попадают только недостижимые блоки.
+func (b *LLVMBuilder) finishFunction() {
+	for _, block := range b.fn.Blocks {
+		if isTerminated(block) {
+			continue
+		}
+		if b.fn.Sig.RetType.Equal(types.Void) {
+			block.NewRet(nil)
+		} else {
+			block.NewUnreachable()
+		}
+	}
 }
 
-func (b *LLVMBuilder) ensureVar(name string) value.Value {
-	if ptr, ok := b.vars[name]; ok {
-		return ptr
+// Предикаты сравнений: целые сравниваются со знаком, вещественные — упорядоченно
+// (кроме non-equal, который, как и в интерпретаторе, истинен для NaN)
+var intPredicates = map[string]enum.IPred{
+	"equal":         enum.IPredEQ,
+	"non-equal":     enum.IPredNE,
+	"less":          enum.IPredSLT,
+	"more":          enum.IPredSGT,
+	"less-or-equal": enum.IPredSLE,
+	"more-or-equal": enum.IPredSGE,
+}
+
+var floatPredicates = map[string]enum.FPred{
+	"equal":         enum.FPredOEQ,
+	"non-equal":     enum.FPredUNE,
+	"less":          enum.FPredOLT,
+	"more":          enum.FPredOGT,
+	"less-or-equal": enum.FPredOLE,
+	"more-or-equal": enum.FPredOGE,
+}
+
+// isFloatOp выбирает между целой и вещественной операцией по типу результата из TAC.
+// Для арифметики он совпадает с типом операндов. Сравнения так выбирать нельзя:
+// их результат всегда boolean, поэтому они смотрят на тип значения операнда.
+// Без SetTypes типа в TAC нет, и решает тип значения.
+func isFloatOp(instr tac.TACInstruction, operand value.Value) bool {
+	if instr.Type != "" {
+		return instr.Type == "double"
 	}
+	return types.IsFloat(operand.Type())
+}
 
-	var varType types.Type = types.I32 // по умолчанию int
+// setResult запоминает значение временной переменной
+func (b *LLVMBuilder) setResult(instr tac.TACInstruction, val value.Value) {
+	b.checkType(instr, val)
+	b.namedValues[instr.Res] = val
+}
 
-	if t, ok := b.varsTypes[name]; ok {
-		varType = t
+// checkType сверяет значение с типом из TAC. Несовпадение означает, что
+// операция выбрана неверно; без SetTypes в TAC типов нет и проверка пропускается.
+func (b *LLVMBuilder) checkType(instr tac.TACInstruction, val value.Value) {
+	if instr.Type == "" {
+		return
 	}
+	if want, ok := llvmType(instr.Type); ok && !want.Equal(val.Type()) {
+		name := instr.Res
+		if name == "" {
+			name = instr.Arg1
+		}
+		b.errorf(diagnostics.CodeUnsupportedType, "значение %s в %s имеет тип %s, ожидался %s (%s)", name, instr.Op, val.Type(), want, instr.Type)
+	}
+}
+
+// isTerminated сообщает, завершён ли блок терминатором (br, ret и т.п.).
+// Терминатор хранится в Block.Term, а не среди Block.Insts.
+func isTerminated(block *ir.Block) bool {
+	return block.Term != nil
+}
+
+// declareVar выделяет память под переменную. Все alloca ставятся в начало
+// entry-блока, чтобы переменная, объявленная в цикле, не выделялась на каждой итерации.
+func (b *LLVMBuilder) declareVar(name string, varType types.Type) *ir.InstAlloca {
+	if ptr, ok := b.vars[name]; ok && ptr.ElemType.Equal(varType) {
+		return ptr
+	}
+
+	entry := b.fn.Blocks[0]
+	ptr := ir.NewAlloca(varType)
+	entry.Insts = append(entry.Insts, nil)
+	copy(entry.Insts[b.allocaCount+1:], entry.Insts[b.allocaCount:])
+	entry.Insts[b.allocaCount] = ptr
+	b.allocaCount++
 
-	ptr := b.block.NewAlloca(varType)
 	b.vars[name] = ptr
 	return ptr
 }
 
+// declareGlobal создаёт глобальную переменную с нулевым начальным значением;
+// присваивание из инициализатора выполняет main. Префикс g. не даёт имени
+// совпасть с функцией: в идентификаторах языка точки нет.
+func (b *LLVMBuilder) declareGlobal(name string, varType types.Type) *ir.Global {
+	if g, ok := b.globals[name]; ok {
+		return g
+	}
+	var init constant.Constant
+	switch {
+	case types.IsInt(varType):
+		init = constant.NewInt(varType.(*types.IntType), 0)
+	case types.IsFloat(varType):
+		init = constant.NewFloat(varType.(*types.FloatType), 0)
+	default:
+		init = constant.NewNull(varType.(*types.PointerType))
+	}
+	g := b.mod.NewGlobalDef("g."+name, init)
+	b.globals[name] = g
+	return g
+}
+
+// lookupVar находит память переменной: сначала локальную в текущей функции, затем глобальную
+func (b *LLVMBuilder) lookupVar(name string) (value.Value, types.Type, bool) {
+	if ptr, ok := b.vars[name]; ok {
+		return ptr, ptr.ElemType, true
+	}
+	if g, ok := b.globals[name]; ok {
+		return g, g.ContentType, true
+	}
+	return nil, nil, false
+}
+
+// llvmType возвращает тип LLVM для типа языка из инструкции decl
+func llvmType(name string) (types.Type, bool) {
+	switch name {
+	case "int":
+		return types.I32, true
+	case "double":
+		return types.Double, true
+	case "boolean":
+		return types.I1, true
+	case "string":
+		return types.I8Ptr, true
+	default:
+		return nil, false
+	}
+}
+
 func (b *LLVMBuilder) getValue(name string) value.Value {
 	// Временные значения
 	if val, ok := b.namedValues[name]; ok {
@@ -252,42 +612,97 @@ func (b *LLVMBuilder) getValue(name string) value.Value {
 		return constant.NewInt(types.I1, 0)
 	}
 
-	// Целые числа
-	if intVal, err := strconv.Atoi(name); err == nil {
-		return constant.NewInt(types.I32, int64(intVal))
+	// Строковые литералы приходят из TAC в виде Go-литерала в кавычках
+	if strings.HasPrefix(name, `"`) {
+		str, err := strconv.Unquote(name)
+		if err != nil {
+			b.errorf(diagnostics.CodeUnknownValue, "некорректная строковая константа %s", name)
+			return constant.NewNull(types.I8Ptr)
+		}
+		return b.stringLiteral(str)
 	}
 
-	// Переменные
-	if ptr, ok := b.vars[name]; ok {
-		elemType := ptr.Type().(*types.PointerType).ElemType
+	// Числовые константы: TAC нормализует их, но понимаем и исходную запись (0xFF, 1_000, .5)
+	if intVal, err := lexer.ParseIntLiteral(name); err == nil {
+		return constant.NewInt(types.I32, intVal)
+	}
+	if digits := strings.TrimPrefix(name, "-"); len(digits) > 0 && (digits[0] == '.' || digits[0] >= '0' && digits[0] <= '9') {
+		if floatVal, err := lexer.ParseFloatLiteral(name); err == nil {
+			return constant.NewFloat(types.Double, floatVal)
+		}
+	}
 
+	// Переменные
+	if ptr, elemType, ok := b.lookupVar(name); ok {
 		switch elemType {
 		case types.I1:
 			return b.block.NewLoad(types.I1, ptr)
 		case types.I32:
 			return b.block.NewLoad(types.I32, ptr)
+		case types.Double:
+			return b.block.NewLoad(types.Double, ptr)
 		case types.I8Ptr: // i8* — указатель на строку
 			// Для строки просто загрузим указатель i8*
 			return b.block.NewLoad(types.I8Ptr, ptr)
 		default:
-			panic("неподдерживаемый тип переменной: " + name)
+			b.errorf(diagnostics.CodeUnsupportedType, "неподдерживаемый тип переменной %s: %s", name, elemType)
+			return constant.NewUndef(elemType)
 		}
 	}
 
-	panic("неизвестное значение: " + name)
+	b.errorf(diagnostics.CodeUnknownValue, "неизвестное значение: %s", name)
+	return constant.NewInt(types.I32, 0)
+}
+
+// errorf записывает ошибку генерации. Позиции в исходнике у TAC нет, поэтому спан пустой.
+func (b *LLVMBuilder) errorf(code string, format string, args ...interface{}) {
+	b.Diagnostics.Add(diagnostics.Errorf(diagnostics.NoSpan, code, format, args...))
 }
 
 func (b *LLVMBuilder) IR() *ir.Module {
 	return b.mod
 }
 
-func (b *LLVMBuilder) ensureGlobalString(str, name string) *ir.Global {
-	if g, ok := b.globalStrings[name]; ok {
-		return g
+// show печатает значение так же, как интерпретатор: ">> " и значение по его типу.
+// Вещественные печатаются через %g, логические — словами true/false.
+func (b *LLVMBuilder) show(val value.Value) {
+	printf := b.ensurePrintf()
+	switch {
+	case val.Type().Equal(types.I32):
+		b.block.NewCall(printf, b.ensureGlobalString(">> %d\n", ".fmt.int"), val)
+	case val.Type().Equal(types.Double):
+		b.block.NewCall(printf, b.ensureGlobalString(">> %g\n", ".fmt.double"), val)
+	case val.Type().Equal(types.I8Ptr):
+		b.block.NewCall(printf, b.ensureGlobalString(">> %s\n", ".fmt.str"), val)
+	case val.Type().Equal(types.I1):
+		word := b.block.NewSelect(val, b.stringLiteral("true"), b.stringLiteral("false"))
+		b.block.NewCall(printf, b.ensureGlobalString(">> %s\n", ".fmt.str"), word)
+	default:
+		b.errorf(diagnostics.CodeUnsupportedType, "show не поддерживает тип %s", val.Type())
+	}
+}
+
+// ensureGlobalString создаёт (или находит по имени) глобальную строку с завершающим NUL
+// и возвращает указатель i8* на её первый символ
+func (b *LLVMBuilder) ensureGlobalString(str, name string) constant.Constant {
+	global, ok := b.globalStrings[name]
+	if !ok {
+		global = b.mod.NewGlobalDef(name, constant.NewCharArrayFromString(str+"\x00"))
+		global.Immutable = true
+		b.globalStrings[name] = global
 	}
-	global := b.mod.NewGlobalDef(name, constant.NewCharArrayFromString(str))
-	b.globalStrings[name] = global
-	return global
+	zero := constant.NewInt(types.I32, 0)
+	return constant.NewGetElementPtr(global.ContentType, global, zero, zero)
+}
+
+// stringLiteral возвращает указатель на строковую константу; одинаковые строки разделяют один глобал
+func (b *LLVMBuilder) stringLiteral(str string) constant.Constant {
+	if ptr, ok := b.stringLiterals[str]; ok {
+		return ptr
+	}
+	ptr := b.ensureGlobalString(str, b.uniqueGlobalName(".str"))
+	b.stringLiterals[str] = ptr
+	return ptr
 }
 
 func (b *LLVMBuilder) ensurePrintf() *ir.Func {
@@ -299,38 +714,74 @@ func (b *LLVMBuilder) ensurePrintf() *ir.Func {
 	return b.printf
 }
 
-func (b *LLVMBuilder) ensurePauseString() *ir.Global {
-	for _, g := range b.mod.Globals {
-		if g.Name() == ".pause_str" {
-			return g
-		}
+// ensureDeclared объявляет внешнюю функцию или интринсик один раз на модуль
+func (b *LLVMBuilder) ensureDeclared(name string, ret types.Type, params ...types.Type) *ir.Func {
+	if fn, ok := b.helpers[name]; ok {
+		return fn
 	}
-
-	strVal := constant.NewCharArrayFromString("pause\x00")
-	global := b.mod.NewGlobalDef(".pause_str", strVal)
-	global.Immutable = true
-	// b.mod.Globals уже содержит global, не нужно явно добавлять
-	return global
+	irParams := make([]*ir.Param, len(params))
+	for i, t := range params {
+		irParams[i] = ir.NewParam("", t)
+	}
+	fn := b.mod.NewFunc(name, ret, irParams...)
+	b.helpers[name] = fn
+	return fn
 }
 
-func (b *LLVMBuilder) ensureSystemFunc() *ir.Func {
-	for _, fn := range b.mod.Funcs {
-		if fn.Name() == "system" {
-			return fn
-		}
+// ensureIntPow определяет pow.i32(base, exp) — целую степень возведением в квадрат
+// с переполнением i32. При exp = 0 результат 1, а при отрицательном exp, как
+// в интерпретаторе и при свёртке констант, 1 / base**-exp усекается к нулю:
+// 1 для base = 1, ±1 по чётности exp для base = -1 и 0 для остальных.
+func (b *LLVMBuilder) ensureIntPow() *ir.Func {
+	const name = "pow.i32"
+	if fn, ok := b.helpers[name]; ok {
+		return fn
 	}
-	// Объявляем extern int system(i8*)
-
-	//systemType := types.NewFunc(types.I32, types.NewPointer(types.I8))
-	fn := b.mod.NewFunc("system", types.I32, ir.NewParam("", types.NewPointer(types.I8)))
-	fn.Linkage = enum.LinkageExternal
+	base := ir.NewParam("base", types.I32)
+	exp := ir.NewParam("exp", types.I32)
+	fn := b.mod.NewFunc(name, types.I32, base, exp)
+	fn.Linkage = enum.LinkageInternal
+	b.helpers[name] = fn
+
+	entry := fn.NewBlock("entry")
+	negative := fn.NewBlock("negative")
+	loop := fn.NewBlock("loop")
+	body := fn.NewBlock("body")
+	done := fn.NewBlock("done")
+	zero := constant.NewInt(types.I32, 0)
+	one := constant.NewInt(types.I32, 1)
+	minusOne := constant.NewInt(types.I32, -1)
+
+	entry.NewCondBr(entry.NewICmp(enum.IPredSLT, exp, zero), negative, loop)
+
+	oddExp := negative.NewICmp(enum.IPredNE, negative.NewAnd(exp, one), zero)
+	signed := negative.NewSelect(oddExp, minusOne, one)
+	fraction := negative.NewSelect(negative.NewICmp(enum.IPredEQ, base, minusOne), signed, zero)
+	negative.NewRet(negative.NewSelect(negative.NewICmp(enum.IPredEQ, base, one), one, fraction))
+
+	result := loop.NewPhi(ir.NewIncoming(one, entry))
+	factor := loop.NewPhi(ir.NewIncoming(base, entry))
+	rest := loop.NewPhi(ir.NewIncoming(exp, entry))
+	loop.NewCondBr(loop.NewICmp(enum.IPredSGT, rest, zero), body, done)
+
+	odd := body.NewICmp(enum.IPredNE, body.NewAnd(rest, one), zero)
+	nextResult := body.NewSelect(odd, body.NewMul(result, factor), result)
+	nextFactor := body.NewMul(factor, factor)
+	nextRest := body.NewLShr(rest, one)
+	body.NewBr(loop)
+
+	result.Incs = append(result.Incs, ir.NewIncoming(nextResult, body))
+	factor.Incs = append(factor.Incs, ir.NewIncoming(nextFactor, body))
+	rest.Incs = append(rest.Incs, ir.NewIncoming(nextRest, body))
+
+	done.NewRet(result)
 	return fn
 }
 
 func (b *LLVMBuilder) uniqueGlobalName(prefix string) string {
-	return fmt.Sprintf("%s_%d", prefix, len(b.mod.Globals))
+	return fmt.Sprintf("%s.%d", prefix, len(b.mod.Globals))
 }
 
 func (b *LLVMBuilder) uniqueLabel(prefix string) string {
-	return fmt.Sprintf("%s_%d", prefix, len(b.fnMain.Blocks))
+	return fmt.Sprintf("%s_%d", prefix, len(b.fn.Blocks))
 }
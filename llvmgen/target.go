@@ -0,0 +1,54 @@
+package llvmgen
+
+import "sort"
+
+// DefaultTarget — триплет, для которого генерируется IR, если другой не выбран
+const DefaultTarget = "x86_64-unknown-linux-gnu"
+
+// DataLayoutLLVMVersion — основная версия LLVM, из которой взяты раскладки данных
+// в targets. В других версиях LLVM выбирает для тех же триплетов иные раскладки
+// (например, с LLVM 18 у x86_64 появилось i128:128), и clang предупреждает о расхождении.
+const DataLayoutLLVMVersion = 14
+
+// Target описывает платформу: триплет и раскладку данных, которые записываются в модуль
+type Target struct {
+	Triple     string
+	DataLayout string
+}
+
+// targets — поддерживаемые платформы. Раскладка данных — та, что LLVM
+// версии DataLayoutLLVMVersion выбирает для триплета сам
+// (llc -mtriple=... -print-after=verify -print-module-scope).
+var targets = map[string]Target{
+	"x86_64-unknown-linux-gnu": {
+		Triple:     "x86_64-unknown-linux-gnu",
+		DataLayout: "e-m:e-p270:32:32-p271:32:32-p272:64:64-i64:64-f80:128-n8:16:32:64-S128",
+	},
+	"aarch64-linux-gnu": {
+		Triple:     "aarch64-linux-gnu",
+		DataLayout: "e-m:e-i8:8:32-i16:16:32-i64:64-i128:128-n32:64-S128",
+	},
+	"wasm32-wasi": {
+		Triple:     "wasm32-wasi",
+		DataLayout: "e-m:e-p:32:32-p10:8:8-p20:8:8-i64:64-n32:64-S128-ni:1:10:20",
+	},
+}
+
+// LookupTarget находит платформу по триплету; пустая строка означает DefaultTarget
+func LookupTarget(triple string) (Target, bool) {
+	if triple == "" {
+		triple = DefaultTarget
+	}
+	t, ok := targets[triple]
+	return t, ok
+}
+
+// Targets возвращает триплеты всех поддерживаемых платформ по алфавиту
+func Targets() []string {
+	names := make([]string, 0, len(targets))
+	for name := range targets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,17 @@
+package ast
+
+import "compiler_project/lexer"
+
+// AssignNode — присваивание уже объявленной переменной: a = a + 1;
+type AssignNode struct {
+	Variable lexer.Token
+	Value    ExpressionNode
+}
+
+func NewAssignNode(variable lexer.Token, value ExpressionNode) *AssignNode {
+	return &AssignNode{
+		Variable: variable,
+		Value:    value,
+	}
+}
+func (*AssignNode) isExpression() {}
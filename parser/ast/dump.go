@@ -0,0 +1,90 @@
+package ast
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Dump возвращает текстовое представление дерева с отступами (используется драйвером для --emit=ast)
+func Dump(node ExpressionNode) string {
+	var sb strings.Builder
+	dump(&sb, node, 0)
+	return sb.String()
+}
+
+func dump(sb *strings.Builder, node ExpressionNode, depth int) {
+	indent := strings.Repeat("  ", depth)
+
+	switch n := node.(type) {
+	case nil:
+		fmt.Fprintf(sb, "%s<nil>\n", indent)
+	case *StatementsNode:
+		fmt.Fprintf(sb, "%sStatements\n", indent)
+		for _, stmt := range n.CodeStrings {
+			dump(sb, stmt, depth+1)
+		}
+	case *NumberNode:
+		fmt.Fprintf(sb, "%sNumber %s\n", indent, n.Number.Text)
+	case *FloatNode:
+		fmt.Fprintf(sb, "%sFloat %s\n", indent, n.Float.Text)
+	case *StringNode:
+		fmt.Fprintf(sb, "%sString %q\n", indent, n.String.Value)
+	case *BooleanNode:
+		fmt.Fprintf(sb, "%sBoolean %s\n", indent, n.Boolean.Text)
+	case *VariableNode:
+		fmt.Fprintf(sb, "%sVariable %s\n", indent, n.Variable.Text)
+	case *TypedAssignNode:
+		fmt.Fprintf(sb, "%sTypedAssign %s %s\n", indent, n.Type.Type, n.Variable.Text)
+		dump(sb, n.Value, depth+1)
+	case *AssignNode:
+		fmt.Fprintf(sb, "%sAssign %s\n", indent, n.Variable.Text)
+		dump(sb, n.Value, depth+1)
+	case *BinOperationNode:
+		fmt.Fprintf(sb, "%sBinOperation %s\n", indent, n.Operator.Text)
+		dump(sb, n.LeftNode, depth+1)
+		dump(sb, n.RightNode, depth+1)
+	case *UnarOperationNode:
+		fmt.Fprintf(sb, "%sUnarOperation %s\n", indent, n.Operator.Text)
+		dump(sb, n.Operand, depth+1)
+	case *ShowNode:
+		fmt.Fprintf(sb, "%sShow\n", indent)
+		dump(sb, n.Variable, depth+1)
+	case *IfNode:
+		fmt.Fprintf(sb, "%sIf\n", indent)
+		dump(sb, n.Condition, depth+1)
+		dump(sb, n.TrueBranch, depth+1)
+		if n.FalseBranch != nil {
+			fmt.Fprintf(sb, "%s  Else\n", indent)
+			dump(sb, n.FalseBranch, depth+2)
+		}
+	case *WhileNode:
+		fmt.Fprintf(sb, "%sWhile\n", indent)
+		dump(sb, n.Condition, depth+1)
+		dump(sb, n.Body, depth+1)
+	case *FunctionDeclarationNode:
+		params := make([]string, len(n.Params))
+		for i, param := range n.Params {
+			params[i] = param.Type.Type + " " + param.Name.Text
+		}
+		returnType := ""
+		if n.ReturnType != nil {
+			returnType = " " + n.ReturnType.Type
+		}
+		fmt.Fprintf(sb, "%sFunction %s(%s)%s\n", indent, n.Name.Text, strings.Join(params, ", "), returnType)
+		dump(sb, n.Body, depth+1)
+	case *ReturnNode:
+		fmt.Fprintf(sb, "%sReturn\n", indent)
+		if n.Value != nil {
+			dump(sb, n.Value, depth+1)
+		}
+	case *FunctionCallNode:
+		fmt.Fprintf(sb, "%sCall %s\n", indent, n.Name.Text)
+		for _, arg := range n.Arguments {
+			dump(sb, arg, depth+1)
+		}
+	case *TypeNode:
+		fmt.Fprintf(sb, "%sType %s\n", indent, n.Type)
+	default:
+		fmt.Fprintf(sb, "%s%T\n", indent, node)
+	}
+}
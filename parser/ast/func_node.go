@@ -2,14 +2,27 @@ package ast
 
 import "compiler_project/lexer"
 
+// ParamNode — параметр функции с типом: int a
+type ParamNode struct {
+	Type *TypeNode
+	Name lexer.Token
+}
+
+func NewParamNode(typeToken lexer.Token, name lexer.Token) *ParamNode {
+	return &ParamNode{Type: NewTypeNode(typeToken.TypeToken.Name), Name: name}
+}
+
+func (n *ParamNode) isExpression() {}
+
 type FunctionDeclarationNode struct {
-	Name   *lexer.Token
-	Params []*lexer.Token
-	Body   *StatementsNode
+	Name       *lexer.Token
+	Params     []*ParamNode
+	ReturnType *TypeNode // nil, если функция ничего не возвращает
+	Body       *StatementsNode
 }
 
-func NewFunctionDeclarationNode(name *lexer.Token, params []*lexer.Token, body *StatementsNode) *FunctionDeclarationNode {
-	return &FunctionDeclarationNode{Name: name, Params: params, Body: body}
+func NewFunctionDeclarationNode(name *lexer.Token, params []*ParamNode, returnType *TypeNode, body *StatementsNode) *FunctionDeclarationNode {
+	return &FunctionDeclarationNode{Name: name, Params: params, ReturnType: returnType, Body: body}
 }
 
 func (n *FunctionDeclarationNode) isExpression() {}
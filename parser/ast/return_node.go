@@ -0,0 +1,15 @@
+package ast
+
+import "compiler_project/lexer"
+
+// ReturnNode — выход из функции: return выражение; или просто return;
+type ReturnNode struct {
+	Keyword lexer.Token
+	Value   ExpressionNode // nil для return без значения
+}
+
+func NewReturnNode(keyword lexer.Token, value ExpressionNode) *ReturnNode {
+	return &ReturnNode{Keyword: keyword, Value: value}
+}
+
+func (n *ReturnNode) isExpression() {}
@@ -0,0 +1,54 @@
+package ast
+
+import "compiler_project/diagnostics"
+
+// SpanOf возвращает участок исходника, покрываемый узлом (по токенам, которые в нём сохранены)
+func SpanOf(node ExpressionNode) diagnostics.Span {
+	switch n := node.(type) {
+	case *NumberNode:
+		return n.Number.Span()
+	case *FloatNode:
+		return n.Float.Span()
+	case *StringNode:
+		return n.String.Span()
+	case *BooleanNode:
+		return n.Boolean.Span()
+	case *VariableNode:
+		return n.Variable.Span()
+	case *TypedAssignNode:
+		return n.Variable.Span().Merge(SpanOf(n.Value))
+	case *AssignNode:
+		return n.Variable.Span().Merge(SpanOf(n.Value))
+	case *BinOperationNode:
+		return SpanOf(n.LeftNode).Merge(n.Operator.Span()).Merge(SpanOf(n.RightNode))
+	case *UnarOperationNode:
+		return n.Operator.Span().Merge(SpanOf(n.Operand))
+	case *ShowNode:
+		return SpanOf(n.Variable)
+	case *IfNode:
+		return SpanOf(n.Condition)
+	case *WhileNode:
+		return SpanOf(n.Condition)
+	case *FunctionDeclarationNode:
+		return n.Name.Span()
+	case *ReturnNode:
+		if n.Value == nil {
+			return n.Keyword.Span()
+		}
+		return n.Keyword.Span().Merge(SpanOf(n.Value))
+	case *FunctionCallNode:
+		span := n.Name.Span()
+		for _, arg := range n.Arguments {
+			span = span.Merge(SpanOf(arg))
+		}
+		return span
+	case *StatementsNode:
+		span := diagnostics.NoSpan
+		for _, stmt := range n.CodeStrings {
+			span = span.Merge(SpanOf(stmt))
+		}
+		return span
+	default:
+		return diagnostics.NoSpan
+	}
+}
@@ -1,20 +1,24 @@
 package parser
 
 import (
+	"compiler_project/diagnostics"
 	"compiler_project/lexer"
 	"compiler_project/parser/ast"
+	"errors"
 	"fmt"
+	"math"
 	"strconv"
+	"strings"
 )
 
 type Parser struct {
-	Tokens   []lexer.Token
-	Position int
-	Scope    map[string]interface{}
+	Tokens      []lexer.Token
+	Position    int
+	Diagnostics diagnostics.List
 }
 
 func NewParser(tokens []lexer.Token) *Parser {
-	return &Parser{Tokens: tokens, Scope: map[string]interface{}{}}
+	return &Parser{Tokens: tokens}
 }
 
 func (p *Parser) Match(expected ...lexer.TokenType) *lexer.Token {
@@ -34,25 +38,156 @@ func (p *Parser) Match(expected ...lexer.TokenType) *lexer.Token {
 func (p *Parser) Require(expected ...lexer.TokenType) *lexer.Token {
 	tok := p.Match(expected...)
 	if tok == nil {
-		panic(fmt.Sprintf("Ожидался токен из множества: %v", expected))
+		names := make([]string, len(expected))
+		for i, exp := range expected {
+			names[i] = describeTokenType(exp)
+		}
+		p.fail(diagnostics.CodeUnexpectedToken, "ожидалось %s", strings.Join(names, " или "))
 	}
 	return tok
 }
 
+// fail прерывает разбор синтаксической ошибкой на текущем токене.
+// Паника перехватывается в ParseCode и превращается в диагностику.
+func (p *Parser) fail(code string, format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	if p.Position >= len(p.Tokens) {
+		panic(diagnostics.Errorf(p.endSpan(), diagnostics.CodeUnexpectedEOF, "%s, но файл закончился", message))
+	}
+	current := p.Tokens[p.Position]
+	panic(diagnostics.Errorf(current.Span(), code, "%s, найдено %q", message, current.Text))
+}
+
+// endSpan указывает на место сразу после последнего токена
+func (p *Parser) endSpan() diagnostics.Span {
+	if len(p.Tokens) == 0 {
+		return diagnostics.Span{Start: 0, End: 0}
+	}
+	end := p.Tokens[len(p.Tokens)-1].Span().End
+	return diagnostics.Span{Start: end, End: end}
+}
+
+// describeTokenType возвращает человекочитаемое имя типа токена для сообщений
+func describeTokenType(tt lexer.TokenType) string {
+	switch tt.Name {
+	case "VARIABLE":
+		return "идентификатор"
+	case "INTEGER":
+		return "целое число"
+	case "DOUBLE":
+		return "вещественное число"
+	case "STRING":
+		return "строка"
+	default:
+		return fmt.Sprintf("%q", tt.Literal())
+	}
+}
+
+// ParseCode разбирает программу целиком. Синтаксические ошибки записываются в
+// p.Diagnostics, после каждой парсер синхронизируется на ';' или '}' и продолжает,
+// поэтому возвращается частичное дерево со всеми корректными инструкциями.
 func (p *Parser) ParseCode() *ast.StatementsNode {
-	var statements ast.StatementsNode
+	statements := &ast.StatementsNode{}
 	tokenTypes := *lexer.TokenTypeList
 
 	// Парсим выражения и добавляем их в statements
 	for p.Position < len(p.Tokens) {
-		stmt := p.ParseStatement()
-		statements.AddNode(stmt)
-		p.Require(tokenTypes["SEMICOLON"]) // Добавляем каждое выражение в список
+		// Лишняя '}' на верхнем уровне: сообщаем и пропускаем её
+		if brace := p.Match(tokenTypes["RBRACE"]); brace != nil {
+			p.report(diagnostics.Errorf(brace.Span(), diagnostics.CodeUnexpectedToken, "лишняя \"}\""))
+			continue
+		}
+		if stmt := p.parseTerminatedStatement(); stmt != nil {
+			statements.AddNode(stmt)
+		}
+	}
+	return statements
+}
+
+// parseTerminatedStatement разбирает инструкцию вместе с завершающей ';'.
+// Если не хватает только ';', инструкция сохраняется; если ошибка внутри
+// самой инструкции, возвращается nil.
+func (p *Parser) parseTerminatedStatement() (stmt ast.ExpressionNode) {
+	types := *lexer.TokenTypeList
+
+	defer func() {
+		if r := recover(); r != nil {
+			p.recoverFrom(r)
+		}
+	}()
+
+	stmt = p.ParseStatement()
+	p.Require(types["SEMICOLON"])
+	return stmt
+}
+
+// parseBlock разбирает "{ инструкция; ... }"
+func (p *Parser) parseBlock() *ast.StatementsNode {
+	types := *lexer.TokenTypeList
+
+	p.Require(types["LBRACE"]) // {
+
+	block := &ast.StatementsNode{}
+	for p.Match(types["RBRACE"]) == nil { // }
+		if p.Position >= len(p.Tokens) {
+			p.fail(diagnostics.CodeUnexpectedEOF, "ожидалось \"}\"")
+		}
+		if stmt := p.parseTerminatedStatement(); stmt != nil {
+			block.AddNode(stmt)
+		}
+	}
+	return block
+}
+
+// recoverFrom записывает синтаксическую ошибку из паники и синхронизирует парсер
+func (p *Parser) recoverFrom(r interface{}) {
+	d, ok := r.(diagnostics.Diagnostic)
+	if !ok {
+		panic(r)
+	}
+	p.report(d)
+	p.synchronize()
+}
+
+// report записывает ошибку, если о том же месте ещё не сообщали
+func (p *Parser) report(d diagnostics.Diagnostic) {
+	for _, prev := range p.Diagnostics {
+		if prev.Span.Start == d.Span.Start {
+			return
+		}
+	}
+	p.Diagnostics.Add(d)
+}
+
+// synchronize пропускает токены до ';' (поглощая её) или до '}' текущего уровня
+// вложенности (оставляя её для разбора блока)
+func (p *Parser) synchronize() {
+	types := *lexer.TokenTypeList
+
+	depth := 0
+	for p.Position < len(p.Tokens) {
+		switch p.Tokens[p.Position].TypeToken {
+		case types["SEMICOLON"]:
+			if depth == 0 {
+				p.Position++
+				return
+			}
+		case types["LBRACE"]:
+			depth++
+		case types["RBRACE"]:
+			if depth == 0 {
+				return
+			}
+			depth--
+		}
+		p.Position++
 	}
-	return &statements
 }
 
 func (p *Parser) ParseStatement() ast.ExpressionNode {
+	if p.Position >= len(p.Tokens) {
+		p.fail(diagnostics.CodeUnexpectedEOF, "ожидалась инструкция")
+	}
 	current := p.Tokens[p.Position]
 
 	switch current.TypeToken.Name {
@@ -67,6 +202,13 @@ func (p *Parser) ParseStatement() ast.ExpressionNode {
 		return p.parseIfStatement()
 	case "while":
 		return p.parseWhileStatement()
+	case "return":
+		return p.parseReturnStatement()
+	case "VARIABLE":
+		if p.Position+1 < len(p.Tokens) && isAssignmentOperator(p.Tokens[p.Position+1].TypeToken) {
+			return p.parseAssignment()
+		}
+		return p.ParseExpression()
 	default:
 		expr := p.ParseExpression()
 		return expr
@@ -82,11 +224,12 @@ func (p *Parser) parseFunctionDeclaration() ast.ExpressionNode {
 
 	p.Require(types["LPAREN"])
 
-	var params []*lexer.Token
+	var params []*ast.ParamNode
 	if p.Match(types["RPAREN"]) == nil {
 		for {
-			param := p.Require(types["VARIABLE"])
-			params = append(params, param)
+			paramType := p.Require(types["INT"], types["DOUB"], types["STR"], types["BOOLEAN"])
+			paramName := p.Require(types["VARIABLE"])
+			params = append(params, ast.NewParamNode(*paramType, *paramName))
 
 			if p.Match(types["COMMA"]) == nil {
 				break
@@ -95,16 +238,26 @@ func (p *Parser) parseFunctionDeclaration() ast.ExpressionNode {
 		p.Require(types["RPAREN"])
 	}
 
-	p.Require(types["LBRACE"])
-
-	body := &ast.StatementsNode{}
-	for p.Match(types["RBRACE"]) == nil {
-		stmt := p.ParseStatement()
-		body.AddNode(stmt)
-		p.Require(types["SEMICOLON"])
+	// Тип результата необязателен: без него функция ничего не возвращает
+	var returnType *ast.TypeNode
+	if typeToken := p.Match(types["INT"], types["DOUB"], types["STR"], types["BOOLEAN"]); typeToken != nil {
+		returnType = ast.NewTypeNode(typeToken.TypeToken.Name)
 	}
 
-	return ast.NewFunctionDeclarationNode(name, params, body)
+	body := p.parseBlock()
+
+	return ast.NewFunctionDeclarationNode(name, params, returnType, body)
+}
+
+// parseReturnStatement разбирает "return выражение" или "return" без значения
+func (p *Parser) parseReturnStatement() ast.ExpressionNode {
+	types := *lexer.TokenTypeList
+
+	keyword := p.Require(types["RETURN"])
+	if p.Position < len(p.Tokens) && p.Tokens[p.Position].TypeToken == types["SEMICOLON"] {
+		return ast.NewReturnNode(*keyword, nil)
+	}
+	return ast.NewReturnNode(*keyword, p.parseFormula())
 }
 
 func (p *Parser) parseIfStatement() ast.ExpressionNode {
@@ -113,25 +266,11 @@ func (p *Parser) parseIfStatement() ast.ExpressionNode {
 	p.Require(types["IF"])
 
 	condition := p.ParseExpression()
-	p.Require(types["LBRACE"]) // {
-
-	trueBranch := &ast.StatementsNode{}
-	for p.Match(types["RBRACE"]) == nil { // }
-		stmt := p.ParseStatement()
-		trueBranch.AddNode(stmt)
-		p.Require(types["SEMICOLON"])
-	}
+	trueBranch := p.parseBlock()
 
 	var falseBranch *ast.StatementsNode
 	if p.Match(types["ELSE"]) != nil {
-		p.Require(types["LBRACE"]) // {
-
-		falseBranch = &ast.StatementsNode{}
-		for p.Match(types["RBRACE"]) == nil { // }
-			stmt := p.ParseStatement()
-			falseBranch.AddNode(stmt)
-			p.Require(types["SEMICOLON"])
-		}
+		falseBranch = p.parseBlock()
 	}
 
 	return ast.NewIfNode(condition, trueBranch, falseBranch)
@@ -142,9 +281,8 @@ func (p *Parser) parseShowStatement() ast.ExpressionNode {
 
 	p.Require(tokenTypes["SHOW"])
 
-	var_name := p.Require(tokenTypes["VARIABLE"]) // имя переменной
-	variable := ast.NewVariableNode(*var_name)
-	return ast.NewShowNode(variable)
+	// show печатает значение любого выражения: show x; show a + b;
+	return ast.NewShowNode(p.parseFormula())
 }
 
 func (p *Parser) ParseExpression() ast.ExpressionNode {
@@ -155,6 +293,49 @@ func (p *Parser) ParseExpression() ast.ExpressionNode {
 	return p.parseFormula()
 }
 
+// compoundOperators — во что раскрываются составные присваивания,
+// инкремент и декремент: a += b → a = a + b, i++ → i = i + 1
+var compoundOperators = map[string]string{
+	"PLUSASSIGN":     "PLUS",
+	"MINUSASSIGN":    "MINUS",
+	"MULTIPLYASSIGN": "MULTIPLY",
+	"DIVIDEASSIGN":   "DIVIDE",
+	"MODULOASSIGN":   "MODULO",
+	"INCREMENT":      "PLUS",
+	"DECREMENT":      "MINUS",
+}
+
+func isAssignmentOperator(tt lexer.TokenType) bool {
+	_, compound := compoundOperators[tt.Name]
+	return compound || tt.Name == "ASSIGN"
+}
+
+// parseAssignment разбирает присваивание без объявления типа: a = a + 1.
+// Составные формы (a += 1, i++) сразу раскрываются в AssignNode над BinOperationNode,
+// поэтому дальше по конвейеру отдельной поддержки не требуют.
+func (p *Parser) parseAssignment() ast.ExpressionNode {
+	types := *lexer.TokenTypeList
+	variable := p.Require(types["VARIABLE"])
+	if p.Match(types["ASSIGN"]) != nil {
+		value := p.parseFormula()
+		return ast.NewAssignNode(*variable, value)
+	}
+
+	op := p.Require(types["PLUSASSIGN"], types["MINUSASSIGN"], types["MULTIPLYASSIGN"],
+		types["DIVIDEASSIGN"], types["MODULOASSIGN"], types["INCREMENT"], types["DECREMENT"])
+
+	var value ast.ExpressionNode
+	if op.TypeToken == types["INCREMENT"] || op.TypeToken == types["DECREMENT"] {
+		value = ast.NewNumberNode(lexer.Token{TypeToken: types["INTEGER"], Text: "1", Pos: op.Pos})
+	} else {
+		value = p.parseFormula()
+	}
+
+	// Оператор сохраняет исходный текст ("+=") и позицию, чтобы ошибки указывали на него
+	binOp := lexer.Token{TypeToken: types[compoundOperators[op.TypeToken.Name]], Text: op.Text, Pos: op.Pos}
+	return ast.NewAssignNode(*variable, ast.NewBinOperationNode(binOp, ast.NewVariableNode(*variable), value))
+}
+
 func (p *Parser) parseTypedAssignment() ast.ExpressionNode {
 	types := *lexer.TokenTypeList
 	typeToken := p.Match(types["INT"], types["DOUB"], types["STR"], types["BOOLEAN"])
@@ -167,6 +348,24 @@ func (p *Parser) parseTypedAssignment() ast.ExpressionNode {
 	return ast.NewTypedAssignNode(*typeToken, *variable, value)
 }
 
+// parseFormula разбирает выражение. Приоритеты операторов, от слабых к сильным
+// (каждому уровню соответствует своя функция parse*):
+//
+//	or ||                                            parseLogicalOr
+//	and &&                                           parseLogicalAnd
+//	equal non-equal == !=                            parseEquality
+//	more less more-or-equal less-or-equal < > <= >=  parseComparison
+//	|                                                parseBitOr
+//	^                                                parseBitXor
+//	&                                                parseBitAnd
+//	<< >>                                            parseShift
+//	+ -                                              parseTerm
+//	* / %                                            parseFactor
+//	унарные - not !                                  parseUnary
+//	**                                               parsePower
+//
+// Все бинарные операторы левоассоциативны, кроме **: 2 ** 3 ** 2 = 2 ** (3 ** 2).
+// ** сильнее унарного минуса слева и слабее справа: -2 ** 2 = -(2 ** 2), 2 ** -1 допустимо.
 func (p *Parser) parseFormula() ast.ExpressionNode {
 	return p.parseLogicalOr()
 }
@@ -175,7 +374,7 @@ func (p *Parser) parseLogicalOr() ast.ExpressionNode {
 	node := p.parseLogicalAnd()
 	types := *lexer.TokenTypeList
 	for {
-		op := p.Match(types["OR"])
+		op := p.Match(types["OR"], types["LOR"])
 		if op == nil {
 			break
 		}
@@ -189,7 +388,7 @@ func (p *Parser) parseLogicalAnd() ast.ExpressionNode {
 	node := p.parseEquality()
 	types := *lexer.TokenTypeList
 	for {
-		op := p.Match(types["AND"])
+		op := p.Match(types["AND"], types["LAND"])
 		if op == nil {
 			break
 		}
@@ -200,10 +399,83 @@ func (p *Parser) parseLogicalAnd() ast.ExpressionNode {
 }
 
 func (p *Parser) parseEquality() ast.ExpressionNode {
+	node := p.parseComparison()
+	types := *lexer.TokenTypeList
+	for {
+		op := p.Match(types["EQUAL"], types["NONEQUAL"], types["EQ"], types["NEQ"])
+		if op == nil {
+			break
+		}
+		right := p.parseComparison()
+		node = ast.NewBinOperationNode(*op, node, right)
+	}
+	return node
+}
+
+// parseComparison — сравнения по порядку связывают сильнее, чем равенство:
+// a < b == c < d разбирается как (a < b) == (c < d)
+func (p *Parser) parseComparison() ast.ExpressionNode {
+	node := p.parseBitOr()
+	types := *lexer.TokenTypeList
+	for {
+		op := p.Match(types["MORE"], types["LESS"], types["MOREOREQUAL"], types["LESSOREQUAL"],
+			types["GT"], types["LT"], types["GE"], types["LE"])
+		if op == nil {
+			break
+		}
+		right := p.parseBitOr()
+		node = ast.NewBinOperationNode(*op, node, right)
+	}
+	return node
+}
+
+func (p *Parser) parseBitOr() ast.ExpressionNode {
+	node := p.parseBitXor()
+	types := *lexer.TokenTypeList
+	for {
+		op := p.Match(types["BITOR"])
+		if op == nil {
+			break
+		}
+		right := p.parseBitXor()
+		node = ast.NewBinOperationNode(*op, node, right)
+	}
+	return node
+}
+
+func (p *Parser) parseBitXor() ast.ExpressionNode {
+	node := p.parseBitAnd()
+	types := *lexer.TokenTypeList
+	for {
+		op := p.Match(types["BITXOR"])
+		if op == nil {
+			break
+		}
+		right := p.parseBitAnd()
+		node = ast.NewBinOperationNode(*op, node, right)
+	}
+	return node
+}
+
+func (p *Parser) parseBitAnd() ast.ExpressionNode {
+	node := p.parseShift()
+	types := *lexer.TokenTypeList
+	for {
+		op := p.Match(types["BITAND"])
+		if op == nil {
+			break
+		}
+		right := p.parseShift()
+		node = ast.NewBinOperationNode(*op, node, right)
+	}
+	return node
+}
+
+func (p *Parser) parseShift() ast.ExpressionNode {
 	node := p.parseTerm()
 	types := *lexer.TokenTypeList
 	for {
-		op := p.Match(types["EQUAL"], types["NONEQUAL"], types["MORE"], types["LESS"])
+		op := p.Match(types["SHL"], types["SHR"])
 		if op == nil {
 			break
 		}
@@ -228,19 +500,68 @@ func (p *Parser) parseTerm() ast.ExpressionNode {
 }
 
 func (p *Parser) parseFactor() ast.ExpressionNode {
-	node := p.parsePrimary()
+	node := p.parseUnary()
 	types := *lexer.TokenTypeList
 	for {
-		op := p.Match(types["MULTIPLY"], types["DIVIDE"])
+		op := p.Match(types["MULTIPLY"], types["DIVIDE"], types["MODULO"])
 		if op == nil {
 			break
 		}
-		right := p.parsePrimary()
+		right := p.parseUnary()
 		node = ast.NewBinOperationNode(*op, node, right)
 	}
 	return node
 }
 
+// parseUnary — префиксные "-", "not" и "!" связывают сильнее бинарных операторов
+// и могут повторяться: - -a, not !flag
+func (p *Parser) parseUnary() ast.ExpressionNode {
+	types := *lexer.TokenTypeList
+	if op := p.Match(types["MINUS"], types["NOT"], types["LNOT"]); op != nil {
+		if op.TypeToken == types["MINUS"] {
+			if number := p.negativeLiteral(*op); number != nil {
+				return number
+			}
+		}
+		return ast.NewUnarOperationNode(*op, p.parseUnary())
+	}
+	return p.parsePower()
+}
+
+// negativeLiteral склеивает минус со следующим за ним целым, которое помещается
+// в int только со знаком: -2147483648. Остальные литералы остаются операндом
+// унарного минуса, а в -2147483648 ** 2 число — операнд степени и проверяется
+// как обычно в parsePrimary.
+func (p *Parser) negativeLiteral(minus lexer.Token) ast.ExpressionNode {
+	types := *lexer.TokenTypeList
+	if p.Position >= len(p.Tokens) || p.Tokens[p.Position].TypeToken != types["INTEGER"] {
+		return nil
+	}
+	if next := p.Position + 1; next < len(p.Tokens) && p.Tokens[next].TypeToken == types["POWER"] {
+		return nil
+	}
+	number := p.Tokens[p.Position]
+	if _, err := lexer.ParseIntLiteral(number.Text); !errors.Is(err, strconv.ErrRange) {
+		return nil
+	}
+	text := "-" + number.Text
+	if _, err := lexer.ParseIntLiteral(text); err != nil {
+		return nil
+	}
+	p.Position++
+	return ast.NewNumberNode(lexer.Token{TypeToken: number.TypeToken, Text: text, Pos: minus.Pos})
+}
+
+// parsePower — возведение в степень правоассоциативно, показатель может быть унарным
+func (p *Parser) parsePower() ast.ExpressionNode {
+	types := *lexer.TokenTypeList
+	node := p.parsePrimary()
+	if op := p.Match(types["POWER"]); op != nil {
+		node = ast.NewBinOperationNode(*op, node, p.parseUnary())
+	}
+	return node
+}
+
 func (p *Parser) parsePrimary() ast.ExpressionNode {
 	types := *lexer.TokenTypeList
 
@@ -254,18 +575,21 @@ func (p *Parser) parsePrimary() ast.ExpressionNode {
 		return ast.NewBooleanNode(*b)
 	}
 	if number := p.Match(types["INTEGER"]); number != nil {
+		if _, err := lexer.ParseIntLiteral(number.Text); errors.Is(err, strconv.ErrRange) {
+			d := diagnostics.Errorf(number.Span(), diagnostics.CodeIntOverflow,
+				"целое число %s не помещается в 32 бита (максимум %d)", number.Text, math.MaxInt32)
+			if _, err := lexer.ParseIntLiteral("-" + number.Text); err == nil {
+				d = d.WithNote("%s допустимо только сразу после унарного минуса: -%s", number.Text, number.Text)
+			}
+			p.report(d)
+		}
 		return ast.NewNumberNode(*number)
 	}
 	if flt := p.Match(types["DOUBLE"]); flt != nil {
 		return ast.NewFloatNode(*flt)
 	}
 	if str := p.Match(types["STRING"]); str != nil {
-		// Убираем обрамляющие кавычки (например, "asdf" → asdf)
-		cleaned := str.Text
-		if len(cleaned) >= 2 && cleaned[0] == '"' && cleaned[len(cleaned)-1] == '"' {
-			cleaned = cleaned[1 : len(cleaned)-1]
-		}
-		str.Text = cleaned
+		// Кавычки и escape-последовательности уже обработаны лексером (str.Value)
 		return ast.NewStringNode(*str)
 	}
 
@@ -288,7 +612,8 @@ func (p *Parser) parsePrimary() ast.ExpressionNode {
 		return ast.NewVariableNode(*variable)
 	}
 
-	panic("Ожидалось выражение")
+	p.fail(diagnostics.CodeExpectedExpression, "ожидалось выражение")
+	return nil
 }
 
 func (p *Parser) parseWhileStatement() ast.ExpressionNode {
@@ -297,160 +622,7 @@ func (p *Parser) parseWhileStatement() ast.ExpressionNode {
 	p.Require(types["WHILE"])
 
 	condition := p.ParseExpression()
-
-	p.Require(types["LBRACE"])
-
-	body := &ast.StatementsNode{}
-	for p.Match(types["RBRACE"]) == nil { // }
-		stmt := p.ParseStatement()
-		body.AddNode(stmt)
-		p.Require(types["SEMICOLON"])
-	}
+	body := p.parseBlock()
 
 	return ast.NewWhileNode(condition, body)
 }
-
-func (p *Parser) Run(node ast.ExpressionNode) interface{} {
-	types := *lexer.TokenTypeList
-
-	switch n := node.(type) {
-	case *ast.NumberNode:
-		val, _ := strconv.Atoi(n.Number.Text)
-		return val
-	case *ast.FloatNode:
-		val, _ := strconv.ParseFloat(n.Float.Text, 64)
-		return val
-	case *ast.StringNode:
-		return n.String.Text
-	case *ast.BooleanNode:
-		return n.Boolean.TypeToken.Name == "TRUE"
-	case *ast.VariableNode:
-		return p.Scope[n.Variable.Text]
-	case *ast.TypedAssignNode:
-		val := p.Run(n.Value)
-
-		p.Scope[n.Variable.Text] = val
-		//fmt.Printf("Добавлена типизированная переменная %s типа %s со значением %v\n", n.Variable.Text, n.Type.Type, val)
-		return val
-	case *ast.StatementsNode:
-		var result interface{}
-		for _, stmt := range n.CodeStrings {
-			result = p.Run(stmt)
-		}
-		return result
-
-	case *ast.ShowNode:
-		val := p.Run(n.Variable)
-		fmt.Printf(">> %v\n", val)
-		return val
-	case *ast.IfNode:
-		cond := p.Run(n.Condition)
-		condVal, _ := cond.(bool)
-		if condVal {
-			return p.Run(n.TrueBranch)
-		}
-		if n.FalseBranch != nil {
-			return p.Run(n.FalseBranch)
-		}
-		return nil
-	case *ast.WhileNode:
-		cond := p.Run(n.Condition)
-		condVal, _ := cond.(bool)
-
-		for condVal {
-			p.Run(n.Body)
-			cond = p.Run(n.Condition)
-			condVal, _ = cond.(bool)
-		}
-		return nil
-
-	case *ast.BinOperationNode:
-		left := p.Run(n.LeftNode)
-		right := p.Run(n.RightNode)
-
-		switch l := left.(type) {
-		case int:
-			r, _ := right.(int)
-			switch n.Operator.TypeToken {
-			case types["PLUS"]:
-				return l + r
-			case types["MINUS"]:
-				return l - r
-			case types["MULTIPLY"]:
-				return l * r
-			case types["DIVIDE"]:
-				return l / r
-			case types["EQUAL"]:
-				return l == r
-			case types["NONEQUAL"]:
-				return l != r
-			case types["MORE"]:
-				return l > r
-			case types["LESS"]:
-				return l < r
-			}
-		case float64:
-			r, _ := right.(float64)
-			switch n.Operator.TypeToken {
-			case types["PLUS"]:
-				return l + r
-			case types["MINUS"]:
-				return l - r
-			case types["MULTIPLY"]:
-				return l * r
-			case types["DIVIDE"]:
-				return l / r
-			}
-		case string:
-			r, _ := right.(string)
-			if n.Operator.TypeToken == types["PLUS"] {
-				return l + r
-			}
-		case bool:
-			r, _ := right.(bool)
-			switch n.Operator.TypeToken {
-			case types["EQUAL"]:
-				return l == r
-			case types["NONEQUAL"]:
-				return l != r
-			case types["AND"]:
-				return l && r
-			case types["OR"]:
-				return l || r
-			}
-		default:
-			panic("Неподдерживаемые типы в бинарной операции")
-		}
-	case *ast.FunctionDeclarationNode:
-		p.Scope[n.Name.Text] = n
-		return nil
-
-	case *ast.FunctionCallNode:
-		fn, ok := p.Scope[n.Name.Text].(*ast.FunctionDeclarationNode)
-		if !ok {
-			panic(fmt.Sprintf("Функция %s не найдена", n.Name.Text))
-		}
-
-		// Сохраняем старый скоуп
-		oldScope := p.Scope
-
-		for i, param := range fn.Params {
-			if i < len(n.Arguments) {
-				// Для аргументов вычисляем их значение
-				p.Scope[param.Text] = p.Run(n.Arguments[i])
-			} else {
-				fmt.Println("Значение не найдено!")
-				p.Scope[param.Text] = nil
-			}
-		}
-
-		result := p.Run(fn.Body)
-		p.Scope = oldScope
-
-		return result
-
-	default:
-		panic("Неизвестная нода")
-	}
-	return nil
-}
@@ -0,0 +1,23 @@
+package semantics
+
+import "compiler_project/diagnostics"
+
+// Builtins — встроенные функции языка. Checker и интерпретатор объявляют их
+// в глобальной области до пользовательских, поэтому переопределить их нельзя.
+var Builtins = []Symbol{
+	// exit(code) завершает программу с кодом возврата code
+	{Name: "exit", Kind: SymbolFunction, Type: "void", Params: []string{"int"}, Span: diagnostics.NoSpan},
+}
+
+// DeclareBuiltins объявляет встроенные функции в области scope
+func DeclareBuiltins(scope *SymbolTable) {
+	for i := range Builtins {
+		sym := Builtins[i]
+		scope.Declare(&sym)
+	}
+}
+
+// IsBuiltin сообщает, что символ — встроенная функция, а не объявленная в программе
+func IsBuiltin(sym *Symbol) bool {
+	return sym.Kind == SymbolFunction && !sym.Span.IsValid()
+}
@@ -0,0 +1,115 @@
+package semantics
+
+import (
+	"compiler_project/diagnostics"
+	"compiler_project/parser/ast"
+)
+
+// Функцию можно вызвать до её объявления, а тело видит глобальные переменные,
+// объявленные выше самой функции. Поэтому вызов с верхнего уровня может выполниться
+// раньше, чем переменная, которую читает функция, получит значение:
+//
+//	int x = f(); int g = 5; func f() int { return g; };
+//
+// Такие вызовы проверяются после всей программы, когда известны тела всех функций.
+
+// globalUse — обращение функции к глобальной переменной
+type globalUse struct {
+	variable *Symbol
+	node     ast.ExpressionNode
+}
+
+// functionUses — глобальные переменные, которые функция читает или присваивает,
+// и пользовательские функции, которые она вызывает
+type functionUses struct {
+	globals []globalUse
+	calls   []*Symbol
+}
+
+// topLevelCall — вызов из кода верхнего уровня; к моменту вызова объявлено
+// declared глобальных переменных
+type topLevelCall struct {
+	fn       *Symbol
+	node     *ast.FunctionCallNode
+	declared int
+}
+
+// declareGlobal запоминает порядок объявления переменной верхнего уровня
+func (tc *TypeChecker) declareGlobal(sym *Symbol) {
+	tc.globals[sym] = len(tc.globals)
+}
+
+// useVariable отмечает обращение проверяемой функции к глобальной переменной
+func (tc *TypeChecker) useVariable(sym *Symbol, node ast.ExpressionNode) {
+	if _, global := tc.globals[sym]; !global || tc.function == nil {
+		return
+	}
+	uses := tc.usesOf(tc.function)
+	uses.globals = append(uses.globals, globalUse{variable: sym, node: node})
+}
+
+// useFunction отмечает вызов пользовательской функции: из тела другой функции
+// или из кода верхнего уровня
+func (tc *TypeChecker) useFunction(fn *Symbol, node *ast.FunctionCallNode) {
+	if IsBuiltin(fn) {
+		return
+	}
+	if tc.function != nil {
+		uses := tc.usesOf(tc.function)
+		uses.calls = append(uses.calls, fn)
+		return
+	}
+	tc.topLevelCalls = append(tc.topLevelCalls, topLevelCall{fn: fn, node: node, declared: len(tc.globals)})
+}
+
+func (tc *TypeChecker) usesOf(fn *Symbol) *functionUses {
+	uses, ok := tc.uses[fn]
+	if !ok {
+		uses = &functionUses{}
+		tc.uses[fn] = uses
+	}
+	return uses
+}
+
+// checkInitOrder запрещает вызовы, во время которых функция (сама или через
+// другие функции) обращается к ещё не объявленной глобальной переменной
+func (tc *TypeChecker) checkInitOrder() error {
+	for _, call := range tc.topLevelCalls {
+		owner, use, ok := tc.lateGlobal(call.fn, call.declared, map[*Symbol]bool{})
+		if !ok {
+			continue
+		}
+		name := use.variable.Name
+		d := diagnostics.Errorf(ast.SpanOf(call.node), diagnostics.CodeUninitializedGlobal,
+			"вызов %s выполняется до объявления глобальной переменной %s", call.node.Name.Text, name).
+			WithNote("перенесите объявление %s выше вызова", name).
+			WithRelated(ast.SpanOf(use.node), "функция %s обращается к %s", owner.Name, name).
+			WithRelated(use.variable.Span, "%s объявлена здесь", name)
+		return d
+	}
+	return nil
+}
+
+// lateGlobal ищет обращение fn или вызываемых ею функций к глобальной переменной
+// с порядковым номером не меньше declared
+func (tc *TypeChecker) lateGlobal(fn *Symbol, declared int, visited map[*Symbol]bool) (*Symbol, globalUse, bool) {
+	if visited[fn] {
+		return nil, globalUse{}, false
+	}
+	visited[fn] = true
+	uses, ok := tc.uses[fn]
+	if !ok {
+		return nil, globalUse{}, false
+	}
+	for _, use := range uses.globals {
+		if tc.globals[use.variable] >= declared {
+			return fn, use, true
+		}
+	}
+	for _, callee := range uses.calls {
+		if owner, use, ok := tc.lateGlobal(callee, declared, visited); ok {
+			return owner, use, true
+		}
+	}
+	return nil, globalUse{}, false
+}
@@ -1 +1,91 @@
 package semantics
+
+import "compiler_project/diagnostics"
+
+// SymbolKind — что именно объявлено под именем
+type SymbolKind int
+
+const (
+	SymbolVariable SymbolKind = iota
+	SymbolFunction
+	SymbolParameter
+)
+
+func (k SymbolKind) String() string {
+	switch k {
+	case SymbolFunction:
+		return "функция"
+	case SymbolParameter:
+		return "параметр"
+	default:
+		return "переменная"
+	}
+}
+
+// Symbol — одно объявление. Checker заполняет типы, интерпретатор хранит в Value
+// текущее значение переменной или узел объявления функции, TAC — уникальное имя.
+type Symbol struct {
+	Name   string
+	Kind   SymbolKind
+	Type   string   // тип переменной или параметра; для функции — тип результата
+	Params []string // типы параметров функции по порядку
+	Span   diagnostics.Span
+	Value  interface{}
+}
+
+// SymbolTable — одна область видимости: верхний уровень программы, тело функции
+// (вместе с её параметрами) или блок if/while. Поиск идёт от внутренней области
+// к внешним, поэтому объявление во вложенном блоке скрывает внешнее с тем же именем.
+type SymbolTable struct {
+	parent  *SymbolTable
+	symbols map[string]*Symbol
+}
+
+// NewSymbolTable создаёт область видимости, вложенную в parent (nil — верхний уровень)
+func NewSymbolTable(parent *SymbolTable) *SymbolTable {
+	return &SymbolTable{parent: parent, symbols: map[string]*Symbol{}}
+}
+
+func (s *SymbolTable) Parent() *SymbolTable {
+	return s.parent
+}
+
+// Global возвращает самую внешнюю область видимости
+func (s *SymbolTable) Global() *SymbolTable {
+	for s.parent != nil {
+		s = s.parent
+	}
+	return s
+}
+
+// IsGlobal сообщает, что область — верхний уровень программы
+func (s *SymbolTable) IsGlobal() bool {
+	return s.parent == nil
+}
+
+// Declare добавляет символ в эту область. Если имя уже объявлено именно здесь,
+// символ не добавляется и возвращается прежнее объявление с false.
+// Объявление с тем же именем во внешней области не мешает: оно скрывается.
+func (s *SymbolTable) Declare(sym *Symbol) (*Symbol, bool) {
+	if existing, ok := s.symbols[sym.Name]; ok {
+		return existing, false
+	}
+	s.symbols[sym.Name] = sym
+	return sym, true
+}
+
+// Lookup ищет имя в этой области и во всех внешних
+func (s *SymbolTable) Lookup(name string) (*Symbol, bool) {
+	for scope := s; scope != nil; scope = scope.parent {
+		if sym, ok := scope.symbols[name]; ok {
+			return sym, true
+		}
+	}
+	return nil, false
+}
+
+// LookupLocal ищет имя только в этой области
+func (s *SymbolTable) LookupLocal(name string) (*Symbol, bool) {
+	sym, ok := s.symbols[name]
+	return sym, ok
+}
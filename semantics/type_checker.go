@@ -1,29 +1,46 @@
 package semantics
 
 import (
-	"compiler_project/lexer"
+	"compiler_project/diagnostics"
 	"compiler_project/parser/ast"
-	"fmt"
 )
 
-type FunctionSignature struct {
-	Params     []string // типы параметров по порядку
-	ReturnType string
-}
-
 type TypeChecker struct {
-	Scope     map[string]string // имя переменной → тип (например: "x" → "int")
-	Functions map[string]FunctionSignature
+	Scope *SymbolTable                  // текущая область видимости; функции объявляются в глобальной
+	Types map[ast.ExpressionNode]string // тип каждого проверенного узла; по нему генерируется TAC
+
+	returnType string  // тип результата проверяемой функции; "" вне функций
+	function   *Symbol // проверяемая функция; nil на верхнем уровне
+	depth      int     // вложенность блоков: функции объявляются только на верхнем уровне
+
+	// Порядок инициализации глобальных переменных (см. init_order.go)
+	globals       map[*Symbol]int
+	uses          map[*Symbol]*functionUses
+	topLevelCalls []topLevelCall
 }
 
 func NewTypeChecker() *TypeChecker {
+	scope := NewSymbolTable(nil)
+	DeclareBuiltins(scope)
 	return &TypeChecker{
-		Scope:     map[string]string{},
-		Functions: map[string]FunctionSignature{},
+		Scope: scope,
+		Types: map[ast.ExpressionNode]string{},
+
+		globals: map[*Symbol]int{},
+		uses:    map[*Symbol]*functionUses{},
 	}
 }
 
+// Check проверяет узел и запоминает его тип в Types
 func (tc *TypeChecker) Check(node ast.ExpressionNode) (string, error) {
+	t, err := tc.check(node)
+	if err == nil {
+		tc.Types[node] = t
+	}
+	return t, err
+}
+
+func (tc *TypeChecker) check(node ast.ExpressionNode) (string, error) {
 	switch n := node.(type) {
 
 	case *ast.NumberNode:
@@ -39,39 +56,82 @@ func (tc *TypeChecker) Check(node ast.ExpressionNode) (string, error) {
 		return "boolean", nil
 
 	case *ast.VariableNode:
-		t, ok := tc.Scope[n.Variable.Text]
-		if !ok {
-			return "", fmt.Errorf("переменная %s не определена", n.Variable.Text)
+		sym, err := tc.lookupVariable(n, n.Variable.Text)
+		if err != nil {
+			return "", err
 		}
-		return t, nil
+		tc.useVariable(sym, n)
+		return sym.Type, nil
 
 	case *ast.TypedAssignNode:
 		declaredType := normalizeTypeName(n.Type.Type)
-		// Временно запоминаем тип переменной, чтобы она была видна внутри Check(n.Value)
-		tc.Scope[n.Variable.Text] = declaredType
-
+		// Значение проверяется до объявления: в int a = a + 1 справа a ещё не существует
 		valType, err := tc.Check(n.Value)
 		if err != nil {
 			return "", err
 		}
 
 		if valType != declaredType {
-			return "", fmt.Errorf("тип переменной %s задан как %s, но присваивается %s", n.Variable.Text, declaredType, valType)
+			return "", errorf(n.Value, diagnostics.CodeTypeMismatch, "тип переменной %s задан как %s, но присваивается %s", n.Variable.Text, declaredType, valType)
+		}
+
+		// Повторное объявление запрещено только в той же области: во вложенном блоке
+		// новая переменная скрывает внешнюю
+		sym := &Symbol{Name: n.Variable.Text, Kind: SymbolVariable, Type: declaredType, Span: n.Variable.Span()}
+		if existing, ok := tc.Scope.Declare(sym); !ok {
+			return "", redeclared(n, existing)
+		}
+		if tc.Scope.IsGlobal() {
+			tc.declareGlobal(sym)
 		}
 
 		return declaredType, nil
 
+	case *ast.AssignNode:
+		sym, ok := tc.Scope.Lookup(n.Variable.Text)
+		if !ok {
+			return "", errorf(n, diagnostics.CodeUndefinedVariable, "присваивание необъявленной переменной %s", n.Variable.Text)
+		}
+		if sym.Kind == SymbolFunction {
+			return "", errorf(n, diagnostics.CodeTypeMismatch, "%s — функция, ей нельзя присвоить значение", n.Variable.Text)
+		}
+		tc.useVariable(sym, n)
+		varType := sym.Type
+
+		valType, err := tc.Check(n.Value)
+		if err != nil {
+			return "", err
+		}
+
+		if valType != varType {
+			return "", errorf(n.Value, diagnostics.CodeTypeMismatch, "переменная %s имеет тип %s, но присваивается %s", n.Variable.Text, varType, valType)
+		}
+
+		return varType, nil
+
 	case *ast.StatementsNode:
+		// На верхнем уровне сигнатуры собираются заранее: функции можно вызывать
+		// до их объявления, в том числе друг из друга
+		program := tc.depth == 0 && tc.Scope.IsGlobal()
+		if program {
+			if err := tc.declareFunctions(n); err != nil {
+				return "", err
+			}
+		}
 		for _, stmt := range n.CodeStrings {
 			_, err := tc.Check(stmt)
 			if err != nil {
 				return "", err
 			}
 		}
+		// Тела всех функций проверены: можно сверить вызовы с порядком объявления глобальных
+		if program {
+			if err := tc.checkInitOrder(); err != nil {
+				return "", err
+			}
+		}
 		return "void", nil
 	case *ast.BinOperationNode:
-		types := *lexer.TokenTypeList
-
 		leftType, err := tc.Check(n.LeftNode)
 		if err != nil {
 			return "", err
@@ -81,12 +141,17 @@ func (tc *TypeChecker) Check(node ast.ExpressionNode) (string, error) {
 			return "", err
 		}
 
-		// Проводим проверку типов для операций EQUAL и NONEQUAL
-		switch n.Operator.TypeToken {
-		case types["EQUAL"], types["NONEQUAL"]:
+		// Парсер раскрывает i++ в i = i + 1 с целой единицей, поэтому ++ и -- есть только у int
+		if text := n.Operator.Text; (text == "++" || text == "--") && leftType != "int" {
+			return "", errorf(n, diagnostics.CodeInvalidOperands, "операция %s определена только для int, получено: %s", text, leftType)
+		}
+
+		// Символьные и словесные формы (== и equal, && и and) проверяются одинаково
+		switch n.Operator.TypeToken.Operator() {
+		case "equal", "non-equal":
 			// Проверка на типы, которые поддерживают операцию сравнения
 			if leftType != rightType {
-				return "", fmt.Errorf("недопустимое сравнение типов: %s и %s", leftType, rightType)
+				return "", errorf(n, diagnostics.CodeInvalidOperands, "недопустимое сравнение типов: %s и %s", leftType, rightType)
 			}
 			// Можно добавить дополнительные проверки для типов, если они должны быть ограничены
 			switch leftType {
@@ -94,34 +159,67 @@ func (tc *TypeChecker) Check(node ast.ExpressionNode) (string, error) {
 				// Поддерживаем сравнение для этих типов
 				return "boolean", nil
 			default:
-				return "", fmt.Errorf("операция %s не поддерживается для типа %s", n.Operator.TypeToken, leftType)
+				return "", errorf(n, diagnostics.CodeInvalidOperands, "операция %s не поддерживается для типа %s", n.Operator.Text, leftType)
 			}
 
 		// Поддержка других типов бинарных операций, например, для чисел
-		case types["MORE"], types["LESS"]:
+		case "more", "less", "more-or-equal", "less-or-equal":
 			if leftType != rightType {
-				return "", fmt.Errorf("недопустимое сравнение типов: %s и %s", leftType, rightType)
+				return "", errorf(n, diagnostics.CodeInvalidOperands, "недопустимое сравнение типов: %s и %s", leftType, rightType)
 			}
 			if leftType == "boolean" {
-				return "", fmt.Errorf("операция %s не поддерживается для типа boolean", n.Operator.TypeToken)
+				return "", errorf(n, diagnostics.CodeInvalidOperands, "операция %s не поддерживается для типа boolean", n.Operator.Text)
 			}
 			return "boolean", nil
 
 		// Остальные бинарные операции, например, AND, OR, которые могут быть логическими
-		case types["AND"], types["OR"]:
+		case "and", "or":
 			if leftType != "boolean" || rightType != "boolean" {
-				return "", fmt.Errorf("логическая операция %s требует типов boolean", n.Operator.TypeToken)
+				return "", errorf(n, diagnostics.CodeInvalidOperands, "логическая операция %s требует типов boolean", n.Operator.Text)
 			}
 			return "boolean", nil
 
-		case types["PLUS"], types["MINUS"], types["MULTIPLY"], types["DIVIDE"]:
+		case "+", "-", "*", "/":
 			if (leftType == "int" || leftType == "double") && leftType == rightType {
 				return leftType, nil
 			}
-			return "", fmt.Errorf("арифметическая операция %s требует совпадающих числовых типов, получено: %s и %s", n.Operator.TypeToken, leftType, rightType)
+			return "", errorf(n, diagnostics.CodeInvalidOperands, "арифметическая операция %s требует совпадающих числовых типов, получено: %s и %s", n.Operator.Text, leftType, rightType)
+
+		case "%", "&", "|", "^", "<<", ">>":
+			if leftType == "int" && rightType == "int" {
+				return "int", nil
+			}
+			return "", errorf(n, diagnostics.CodeInvalidOperands, "операция %s определена только для int, получено: %s и %s", n.Operator.Text, leftType, rightType)
+
+		case "**":
+			// int ** int — целое; вещественное основание допускает и int, и double в показателе
+			if leftType == "int" && rightType == "int" ||
+				leftType == "double" && (rightType == "int" || rightType == "double") {
+				return leftType, nil
+			}
+			return "", errorf(n, diagnostics.CodeInvalidOperands, "возведение в степень требует числовых типов (int ** int, double ** int, double ** double), получено: %s и %s", leftType, rightType)
 
 		default:
-			return "", fmt.Errorf("неподдерживаемая операция %s для типов %s и %s", n.Operator.TypeToken, leftType, rightType)
+			return "", errorf(n, diagnostics.CodeInvalidOperands, "неподдерживаемая операция %s для типов %s и %s", n.Operator.Text, leftType, rightType)
+		}
+	case *ast.UnarOperationNode:
+		operandType, err := tc.Check(n.Operand)
+		if err != nil {
+			return "", err
+		}
+		switch n.Operator.TypeToken.Operator() {
+		case "-":
+			if operandType != "int" && operandType != "double" {
+				return "", errorf(n, diagnostics.CodeInvalidOperands, "унарный минус требует числового типа, получено: %s", operandType)
+			}
+			return operandType, nil
+		case "not":
+			if operandType != "boolean" {
+				return "", errorf(n, diagnostics.CodeInvalidOperands, "логическое отрицание %s требует типа boolean, получено: %s", n.Operator.Text, operandType)
+			}
+			return "boolean", nil
+		default:
+			return "", errorf(n, diagnostics.CodeInvalidOperands, "неподдерживаемая унарная операция %s для типа %s", n.Operator.Text, operandType)
 		}
 	case *ast.IfNode:
 		condType, err := tc.Check(n.Condition)
@@ -129,14 +227,14 @@ func (tc *TypeChecker) Check(node ast.ExpressionNode) (string, error) {
 			return "", err
 		}
 		if condType != "boolean" {
-			return "", fmt.Errorf("условие в if должно быть boolean, получено: %s", condType)
+			return "", errorf(n.Condition, diagnostics.CodeConditionType, "условие в if должно быть boolean, получено: %s", condType)
 		}
-		_, err = tc.Check(n.TrueBranch)
+		_, err = tc.checkBlock(n.TrueBranch)
 		if err != nil {
 			return "", err
 		}
 		if n.FalseBranch != nil {
-			_, err = tc.Check(n.FalseBranch)
+			_, err = tc.checkBlock(n.FalseBranch)
 			if err != nil {
 				return "", err
 			}
@@ -149,64 +247,98 @@ func (tc *TypeChecker) Check(node ast.ExpressionNode) (string, error) {
 			return "", err
 		}
 		if condType != "boolean" {
-			return "", fmt.Errorf("условие в while должно быть boolean, получено: %s", condType)
+			return "", errorf(n.Condition, diagnostics.CodeConditionType, "условие в while должно быть boolean, получено: %s", condType)
 		}
 
 		// Проверка тела цикла
-		_, err = tc.Check(n.Body)
+		_, err = tc.checkBlock(n.Body)
 		if err != nil {
 			return "", err
 		}
 		return "void", nil
 
 	case *ast.ShowNode:
-		_, err := tc.Check(n.Variable)
+		valueType, err := tc.Check(n.Variable)
 		if err != nil {
 			return "", err
 		}
+		if valueType == "void" {
+			return "", errorf(n.Variable, diagnostics.CodeTypeMismatch, "show требует значения, а выражение имеет тип void")
+		}
 		return "void", nil
 
 	case *ast.FunctionDeclarationNode:
-		// Сохраняем сигнатуру функции
-		paramTypes := []string{}
-		for _, param := range n.Params {
-			paramTypes = append(paramTypes, normalizeTypeName(param.TypeToken.Name))
+		if tc.depth > 0 {
+			return "", errorf(n, diagnostics.CodeNestedFunction, "функция %s объявлена внутри блока; функции объявляются только на верхнем уровне", n.Name.Text)
 		}
 
-		// Здесь надо сразу создать сигнатуру, включая тип возврата
-		tc.Functions[n.Name.Text] = FunctionSignature{
-			Params:     paramTypes,
-			ReturnType: "void", // <---- Сейчас у тебя функции всегда "void", потому что не возвращают значение явно
+		// Обычно сигнатура уже объявлена в declareFunctions; сам узел хранится в Value,
+		// чтобы отличить это объявление от повторного с тем же именем
+		fn := functionSymbol(n)
+		if existing, ok := tc.Scope.Declare(fn); !ok {
+			if existing.Value != n {
+				return "", redeclared(n, existing)
+			}
+			fn = existing
 		}
-
-		// Создаём новый скоуп для проверки тела функции
-		oldScope := tc.Scope
-		tc.Scope = make(map[string]string)
+		returnType, paramTypes := fn.Type, fn.Params
+
+		// Тело функции видит глобальные имена, но не локальные переменные вызывающего кода.
+		// Параметры лежат в той же области, что и тело: int a внутри функции с параметром a — ошибка
+		oldScope, oldReturnType, oldFunction := tc.Scope, tc.returnType, tc.function
+		tc.Scope = NewSymbolTable(oldScope.Global())
+		tc.returnType, tc.function = returnType, fn
+		defer func() { tc.Scope, tc.returnType, tc.function = oldScope, oldReturnType, oldFunction }()
 		for i, param := range n.Params {
-			tc.Scope[param.Text] = paramTypes[i]
+			sym := &Symbol{Name: param.Name.Text, Kind: SymbolParameter, Type: paramTypes[i], Span: param.Name.Span()}
+			if existing, ok := tc.Scope.Declare(sym); !ok {
+				return "", redeclared(param, existing)
+			}
 		}
 
-		bodyReturnType, err := tc.Check(n.Body)
+		_, err := tc.checkStatements(n.Body)
 		if err != nil {
 			return "", err
 		}
 
-		// После проверки тела возвращаем старый скоуп
-		tc.Scope = oldScope
-
-		expectedReturnType := tc.Functions[n.Name.Text].ReturnType
-		if expectedReturnType != "void" && bodyReturnType != expectedReturnType {
-			return "", fmt.Errorf("функция %s должна возвращать %s, но возвращает %s", n.Name.Text, expectedReturnType, bodyReturnType)
+		if returnType != "void" && !alwaysReturns(n.Body) {
+			return "", errorf(n, diagnostics.CodeMissingReturn, "функция %s должна возвращать %s, но не на всех путях выполнения есть return", n.Name.Text, returnType)
 		}
 		return "void", nil
 
+	case *ast.ReturnNode:
+		if tc.returnType == "" {
+			return "", errorf(n, diagnostics.CodeReturnOutside, "return вне функции")
+		}
+		if n.Value == nil {
+			if tc.returnType != "void" {
+				return "", errorf(n, diagnostics.CodeReturnType, "return без значения в функции, возвращающей %s", tc.returnType)
+			}
+			return "void", nil
+		}
+		valueType, err := tc.Check(n.Value)
+		if err != nil {
+			return "", err
+		}
+		if tc.returnType == "void" {
+			return "", errorf(n.Value, diagnostics.CodeReturnType, "функция без типа результата не может возвращать значение")
+		}
+		if valueType != tc.returnType {
+			return "", errorf(n.Value, diagnostics.CodeReturnType, "функция должна возвращать %s, но возвращается %s", tc.returnType, valueType)
+		}
+		return valueType, nil
+
 	case *ast.FunctionCallNode:
-		signature, ok := tc.Functions[n.Name.Text]
+		signature, ok := tc.Scope.Lookup(n.Name.Text)
 		if !ok {
-			return "", fmt.Errorf("функция %s не определена", n.Name.Text)
+			return "", errorf(n, diagnostics.CodeUndefinedFunction, "функция %s не определена", n.Name.Text)
+		}
+		if signature.Kind != SymbolFunction {
+			return "", errorf(n, diagnostics.CodeUndefinedFunction, "%s — %s, а не функция", n.Name.Text, signature.Kind)
 		}
+		tc.useFunction(signature, n)
 		if len(n.Arguments) != len(signature.Params) {
-			return "", fmt.Errorf("функция %s ожидает %d аргументов, получено %d", n.Name.Text, len(signature.Params), len(n.Arguments))
+			return "", errorf(n, diagnostics.CodeArgumentCount, "функция %s ожидает %d аргументов, получено %d", n.Name.Text, len(signature.Params), len(n.Arguments))
 		}
 		for i, arg := range n.Arguments {
 			argType, err := tc.Check(arg)
@@ -214,20 +346,109 @@ func (tc *TypeChecker) Check(node ast.ExpressionNode) (string, error) {
 				return "", err
 			}
 			expectedType := signature.Params[i]
-
-			if expectedType == "VARIABLE" {
-				continue
-			}
-
 			if argType != expectedType {
-				return "", fmt.Errorf("в функции %s аргумент %d имеет тип %s, ожидался %s", n.Name.Text, i+1, argType, expectedType)
+				return "", errorf(arg, diagnostics.CodeArgumentType, "в функции %s аргумент %d имеет тип %s, ожидался %s", n.Name.Text, i+1, argType, expectedType)
 			}
 		}
-		return signature.ReturnType, nil
+		return signature.Type, nil
 
 	default:
-		return "", fmt.Errorf("неизвестный тип AST узла: %T", node)
+		return "", errorf(node, diagnostics.CodeUnknownNode, "неизвестный тип AST узла: %T", node)
+	}
+}
+
+// declareFunctions объявляет сигнатуры всех функций верхнего уровня до проверки тел
+func (tc *TypeChecker) declareFunctions(program *ast.StatementsNode) error {
+	for _, stmt := range program.CodeStrings {
+		n, ok := stmt.(*ast.FunctionDeclarationNode)
+		if !ok {
+			continue
+		}
+		if existing, ok := tc.Scope.Declare(functionSymbol(n)); !ok {
+			return redeclared(n, existing)
+		}
 	}
+	return nil
+}
+
+// functionSymbol строит символ функции по её объявлению
+func functionSymbol(n *ast.FunctionDeclarationNode) *Symbol {
+	paramTypes := []string{}
+	for _, param := range n.Params {
+		paramTypes = append(paramTypes, normalizeTypeName(param.Type.Type))
+	}
+	returnType := "void"
+	if n.ReturnType != nil {
+		returnType = normalizeTypeName(n.ReturnType.Type)
+	}
+	return &Symbol{Name: n.Name.Text, Kind: SymbolFunction, Type: returnType, Params: paramTypes, Span: n.Name.Span(), Value: n}
+}
+
+// checkBlock проверяет тело if или while в собственной области видимости
+func (tc *TypeChecker) checkBlock(block *ast.StatementsNode) (string, error) {
+	outer := tc.Scope
+	tc.Scope = NewSymbolTable(outer)
+	defer func() { tc.Scope = outer }()
+	return tc.checkStatements(block)
+}
+
+// checkStatements проверяет вложенный блок в текущей области видимости
+func (tc *TypeChecker) checkStatements(block *ast.StatementsNode) (string, error) {
+	tc.depth++
+	defer func() { tc.depth-- }()
+	return tc.Check(block)
+}
+
+// lookupVariable находит переменную или параметр; имя функции значением не является
+func (tc *TypeChecker) lookupVariable(node ast.ExpressionNode, name string) (*Symbol, error) {
+	sym, ok := tc.Scope.Lookup(name)
+	if !ok {
+		return nil, errorf(node, diagnostics.CodeUndefinedVariable, "переменная %s не определена", name)
+	}
+	if sym.Kind == SymbolFunction {
+		return nil, errorf(node, diagnostics.CodeTypeMismatch, "%s — функция; для вызова используйте %s(...)", name, name)
+	}
+	return sym, nil
+}
+
+// alwaysReturns сообщает, что выполнение блока на любом пути заканчивается return.
+// Цикл while не учитывается: его тело может не выполниться ни разу.
+func alwaysReturns(block *ast.StatementsNode) bool {
+	if block == nil {
+		return false
+	}
+	for _, stmt := range block.CodeStrings {
+		switch n := stmt.(type) {
+		case *ast.ReturnNode:
+			return true
+		case *ast.IfNode:
+			if alwaysReturns(n.TrueBranch) && alwaysReturns(n.FalseBranch) {
+				return true
+			}
+		case *ast.StatementsNode:
+			if alwaysReturns(n) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// redeclared сообщает о повторном объявлении имени в той же области видимости
+func redeclared(node ast.ExpressionNode, existing *Symbol) error {
+	d := diagnostics.Errorf(ast.SpanOf(node), diagnostics.CodeRedeclaration, "имя %s уже объявлено в этой области видимости (%s)", existing.Name, existing.Kind)
+	if existing.Kind != SymbolFunction {
+		d = d.WithNote("для изменения значения используйте %s = ...", existing.Name)
+	}
+	if IsBuiltin(existing) {
+		return d.WithNote("%s — встроенная функция", existing.Name)
+	}
+	return d.WithRelated(existing.Span, "предыдущее объявление %s", existing.Name)
+}
+
+// errorf строит семантическую ошибку, указывающую на узел AST
+func errorf(node ast.ExpressionNode, code string, format string, args ...interface{}) error {
+	return diagnostics.Errorf(ast.SpanOf(node), code, format, args...)
 }
 
 func normalizeTypeName(t string) string {
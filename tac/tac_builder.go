@@ -1,8 +1,14 @@
 package tac
 
 import (
+	"compiler_project/diagnostics"
+	"compiler_project/lexer"
 	"compiler_project/parser/ast" // замени на реальный путь к твоему ast пакету
+	"compiler_project/semantics"
 	"fmt"
+	"io"
+	"math"
+	"os"
 	"strconv"
 	"strings"
 )
@@ -12,21 +18,58 @@ type TACInstruction struct {
 	Arg1 string
 	Arg2 string
 	Res  string
+	Type string // тип результата (int, double, boolean, string); "" если типы не заданы через SetTypes
 }
 
 type TACBuilder struct {
 	instructions []TACInstruction
 	tempCount    int
 	labelCount   int
+	Diagnostics  diagnostics.List
+
+	// scope хранит для каждого объявления его имя в TAC (Symbol.Value).
+	// Переменная, скрывающая внешнюю или повторяющая имя из соседнего блока
+	// той же функции, получает имя вида x.1: точки в идентификаторах языка нет.
+	scope    *semantics.SymbolTable
+	used     map[string]bool // имена, уже занятые в текущей функции
+	versions map[string]int
+
+	types map[ast.ExpressionNode]string // типы узлов из checker
 }
 
 func NewTACBuilder() *TACBuilder {
-	return &TACBuilder{}
+	scope := semantics.NewSymbolTable(nil)
+	semantics.DeclareBuiltins(scope)
+	return &TACBuilder{
+		scope:    scope,
+		used:     map[string]bool{},
+		versions: map[string]int{},
+	}
 }
 
+// TempPrefix начинает имена временных переменных. В идентификаторах языка
+// символа $ нет, поэтому временная переменная не совпадёт с пользовательской.
+const TempPrefix = "$t"
+
 func (b *TACBuilder) newTemp() string {
 	b.tempCount++
-	return fmt.Sprintf("t%d", b.tempCount)
+	return fmt.Sprintf("%s%d", TempPrefix, b.tempCount)
+}
+
+// IsTemp сообщает, что имя принадлежит временной переменной
+func IsTemp(name string) bool {
+	return strings.HasPrefix(name, TempPrefix)
+}
+
+// SetTypes передаёт типы узлов, найденные checker (TypeChecker.Types).
+// Они записываются в поле Type инструкций, и llvmgen выбирает по ним операции.
+func (b *TACBuilder) SetTypes(types map[ast.ExpressionNode]string) {
+	b.types = types
+}
+
+// typeOf возвращает тип узла или "", если типы не заданы
+func (b *TACBuilder) typeOf(node ast.ExpressionNode) string {
+	return b.types[node]
 }
 
 func (b *TACBuilder) Instructions() []TACInstruction {
@@ -41,47 +84,98 @@ func (b *TACBuilder) newLabel() string {
 func (b *TACBuilder) Generate(node ast.ExpressionNode) string {
 	switch n := node.(type) {
 
-	case *ast.NumberNode:
-		return n.Number.Text
-
-	case *ast.FloatNode:
-		return n.Float.Text
+	case *ast.NumberNode, *ast.FloatNode:
+		value, _ := extractConstant(n)
+		return value
 
 	case *ast.StringNode:
-		return fmt.Sprintf(`"%s"`, n.String.Text)
+		// В TAC строка хранится как Go-литерал в кавычках, чтобы escape-последовательности не терялись
+		return strconv.Quote(n.String.Value)
 
 	case *ast.BooleanNode:
 		return n.Boolean.Text
 
 	case *ast.VariableNode:
-		return n.Variable.Text
+		return b.resolve(n.Variable.Text)
 
 	case *ast.TypedAssignNode:
+		// Значение вычисляется до объявления: в int a = a + 1 справа внешняя a
 		val := b.Generate(n.Value)
+		// decl объявляет переменную, чтобы llvmgen выделил под неё память нужного типа;
+		// переменные верхнего уровня объявляются через global, чтобы их видели функции
+		op := "decl"
+		if b.scope.IsGlobal() {
+			op = "global"
+		}
+		name := b.declare(n.Variable.Text, semantics.SymbolVariable)
+		b.instructions = append(b.instructions, TACInstruction{
+			Op:   op,
+			Arg1: n.Type.Type,
+			Res:  name,
+			Type: n.Type.Type,
+		})
 		b.instructions = append(b.instructions, TACInstruction{
 			Op:   "=",
 			Arg1: val,
-			Res:  n.Variable.Text,
+			Res:  name,
+			Type: n.Type.Type,
 		})
-		return n.Variable.Text
+		return name
 
-	case *ast.BinOperationNode:
-		leftConst, leftIsConst := extractConstant(n.LeftNode)
-		rightConst, rightIsConst := extractConstant(n.RightNode)
+	case *ast.AssignNode:
+		val := b.Generate(n.Value)
+		name := b.resolve(n.Variable.Text)
+		b.instructions = append(b.instructions, TACInstruction{
+			Op:   "=",
+			Arg1: val,
+			Res:  name,
+			Type: b.typeOf(n),
+		})
+		return name
 
-		if leftIsConst && rightIsConst {
-			result := evalConstantBinary(n.Operator.Text, leftConst, rightConst)
+	case *ast.BinOperationNode:
+		// В TAC операция записывается канонически: и "<", и "less" дают "less"
+		op := n.Operator.TypeToken.Operator()
+		if result, ok := extractConstant(n); ok {
 			return result
 		}
+		// Целое деление на ноль не сворачивается: ошибку, как и интерпретатор,
+		// выдаёт программа, когда дойдёт до него
+		if right, _ := extractConstant(n.RightNode); (op == "/" || op == "%") && right == "0" {
+			b.Diagnostics.Add(diagnostics.Warningf(ast.SpanOf(n), diagnostics.CodeDivisionByZero,
+				"целочисленное деление на ноль: программа завершится с ошибкой, если выполнит его"))
+		}
+
+		if op == "and" || op == "or" {
+			return b.generateLogical(n, op)
+		}
 
 		left := b.Generate(n.LeftNode)
 		right := b.Generate(n.RightNode)
 		temp := b.newTemp()
 		b.instructions = append(b.instructions, TACInstruction{
-			Op:   n.Operator.Text,
+			Op:   op,
 			Arg1: left,
 			Arg2: right,
 			Res:  temp,
+			Type: b.typeOf(n),
+		})
+		return temp
+
+	case *ast.UnarOperationNode:
+		// Унарный минус записывается как neg, чтобы не путать его с вычитанием
+		op := unaryOp(n)
+		if value, ok := extractConstant(n); ok {
+			return value
+		}
+
+		operand := b.Generate(n.Operand)
+		temp := b.newTemp()
+		b.instructions = append(b.instructions, TACInstruction{
+			Op:   op,
+			Arg1: operand,
+			Res:  temp,
+			Type: b.typeOf(n),
 		})
 		return temp
 
@@ -90,6 +184,7 @@ func (b *TACBuilder) Generate(node ast.ExpressionNode) string {
 		b.instructions = append(b.instructions, TACInstruction{
 			Op:   "show",
 			Arg1: val,
+			Type: b.typeOf(n.Variable),
 		})
 		return ""
 
@@ -110,7 +205,7 @@ func (b *TACBuilder) Generate(node ast.ExpressionNode) string {
 			Res:  elseLabel,
 		})
 
-		b.Generate(n.TrueBranch)
+		b.generateBlock(n.TrueBranch)
 		b.instructions = append(b.instructions, TACInstruction{
 			Op:  "goto",
 			Res: endLabel,
@@ -122,7 +217,7 @@ func (b *TACBuilder) Generate(node ast.ExpressionNode) string {
 		})
 
 		if n.FalseBranch != nil {
-			b.Generate(n.FalseBranch)
+			b.generateBlock(n.FalseBranch)
 		}
 
 		b.instructions = append(b.instructions, TACInstruction{
@@ -147,7 +242,7 @@ func (b *TACBuilder) Generate(node ast.ExpressionNode) string {
 			Res:  endLabel,
 		})
 
-		b.Generate(n.Body)
+		b.generateBlock(n.Body)
 
 		b.instructions = append(b.instructions, TACInstruction{
 			Op:  "goto",
@@ -161,12 +256,34 @@ func (b *TACBuilder) Generate(node ast.ExpressionNode) string {
 		return ""
 
 	case *ast.FunctionDeclarationNode:
+		// func несёт тип результата, за ним идут formal — параметры по порядку
+		returnType := "void"
+		if n.ReturnType != nil {
+			returnType = n.ReturnType.Type
+		}
 		b.instructions = append(b.instructions, TACInstruction{
-			Op:  "func",
-			Res: n.Name.Text,
+			Op:   "func",
+			Arg1: returnType,
+			Res:  n.Name.Text,
+			Type: returnType,
 		})
+		b.scope.Declare(&semantics.Symbol{Name: n.Name.Text, Kind: semantics.SymbolFunction, Value: n.Name.Text})
+
+		// Тело видит только глобальные имена; занятые имена main в функции свободны
+		outerScope, outerUsed := b.scope, b.used
+		b.scope = semantics.NewSymbolTable(outerScope.Global())
+		b.used = map[string]bool{}
+		for _, param := range n.Params {
+			b.instructions = append(b.instructions, TACInstruction{
+				Op:   "formal",
+				Arg1: param.Type.Type,
+				Res:  b.declare(param.Name.Text, semantics.SymbolParameter),
+				Type: param.Type.Type,
+			})
+		}
 
 		b.Generate(n.Body)
+		b.scope, b.used = outerScope, outerUsed
 
 		b.instructions = append(b.instructions, TACInstruction{
 			Op:  "endfunc",
@@ -175,62 +292,196 @@ func (b *TACBuilder) Generate(node ast.ExpressionNode) string {
 
 		return ""
 
+	case *ast.ReturnNode:
+		var val string
+		valType := "void"
+		if n.Value != nil {
+			val = b.Generate(n.Value)
+			valType = b.typeOf(n.Value)
+		}
+		b.instructions = append(b.instructions, TACInstruction{
+			Op:   "return",
+			Arg1: val,
+			Type: valType,
+		})
+		return ""
+
 	case *ast.FunctionCallNode:
+		// Встроенная функция становится отдельной инструкцией: exit $t1
+		if sym, ok := b.scope.Lookup(n.Name.Text); ok && semantics.IsBuiltin(sym) {
+			instr := TACInstruction{Op: sym.Name}
+			if len(n.Arguments) > 0 {
+				instr.Arg1 = b.Generate(n.Arguments[0])
+			}
+			b.instructions = append(b.instructions, instr)
+			return ""
+		}
+
 		var argTemps []string
 		for _, arg := range n.Arguments {
 			tempVar := b.Generate(arg)
 			argTemps = append(argTemps, tempVar)
 		}
 
+		// Аргументы вычисляются все сразу, и только потом передаются через param:
+		// иначе вызовы внутри аргументов (f(g(1), 2)) перемешали бы свои param с нашими
+		for i, arg := range argTemps {
+			b.instructions = append(b.instructions, TACInstruction{
+				Op:   "param",
+				Arg1: arg,
+				Type: b.typeOf(n.Arguments[i]),
+			})
+		}
+
 		resultTemp := b.newTemp()
 		b.instructions = append(b.instructions, TACInstruction{
 			Op:   "call",
 			Arg1: n.Name.Text,
-			Arg2: fmt.Sprintf("%d", len(argTemps)),
+			Arg2: strconv.Itoa(len(argTemps)),
 			Res:  resultTemp,
+			Type: b.typeOf(n),
 		})
 
-		for i, arg := range argTemps {
-			b.instructions = append(b.instructions, TACInstruction{
-				Op:   fmt.Sprintf("arg%d", i),
-				Arg1: arg,
-			})
-		}
-
 		return resultTemp
 
 	default:
-		panic(fmt.Sprintf("неподдерживаемый тип узла: %T", node))
+		b.Diagnostics.Add(diagnostics.Errorf(ast.SpanOf(node), diagnostics.CodeUnsupportedNode,
+			"неподдерживаемый тип узла: %T", node))
+		return ""
+	}
+}
+
+// generateLogical вычисляет and/or с коротким замыканием: правый операнд
+// выполняется, только если левый не определил результат. Результат получают
+// обе ветки, поэтому временная переменная объявляется через decl:
+//
+//	decl boolean $t1          decl boolean $t1
+//	$t1 = a                   $t1 = a
+//	iffalse $t1 goto L1       iffalse $t1 goto L1
+//	$t1 = b                   goto L2
+//	L1:                       L1:
+//	                          $t1 = b
+//	                          L2:
+func (b *TACBuilder) generateLogical(n *ast.BinOperationNode, op string) string {
+	temp := b.newTemp()
+	b.instructions = append(b.instructions, TACInstruction{
+		Op:   "decl",
+		Arg1: "boolean",
+		Res:  temp,
+		Type: "boolean",
+	})
+	b.instructions = append(b.instructions, TACInstruction{
+		Op:   "=",
+		Arg1: b.Generate(n.LeftNode),
+		Res:  temp,
+		Type: "boolean",
+	})
+
+	rightLabel := b.newLabel()
+	b.instructions = append(b.instructions, TACInstruction{
+		Op:   "iffalse",
+		Arg1: temp,
+		Res:  rightLabel,
+	})
+	endLabel := rightLabel
+	if op == "or" {
+		// Истинный левый операнд — уже ответ; иначе переходим к правому
+		endLabel = b.newLabel()
+		b.instructions = append(b.instructions, TACInstruction{
+			Op:  "goto",
+			Res: endLabel,
+		})
+		b.instructions = append(b.instructions, TACInstruction{
+			Op:  "label",
+			Res: rightLabel,
+		})
+	}
+
+	b.instructions = append(b.instructions, TACInstruction{
+		Op:   "=",
+		Arg1: b.Generate(n.RightNode),
+		Res:  temp,
+		Type: "boolean",
+	})
+	b.instructions = append(b.instructions, TACInstruction{
+		Op:  "label",
+		Res: endLabel,
+	})
+	return temp
+}
+
+// generateBlock генерирует тело if или while в собственной области видимости
+func (b *TACBuilder) generateBlock(block *ast.StatementsNode) {
+	outer := b.scope
+	b.scope = semantics.NewSymbolTable(outer)
+	defer func() { b.scope = outer }()
+	b.Generate(block)
+}
+
+// declare объявляет имя в текущей области и возвращает его имя в TAC
+func (b *TACBuilder) declare(name string, kind semantics.SymbolKind) string {
+	unique := name
+	if _, visible := b.scope.Lookup(name); visible || b.used[name] {
+		b.versions[name]++
+		unique = fmt.Sprintf("%s.%d", name, b.versions[name])
+	}
+	b.used[unique] = true
+	b.scope.Declare(&semantics.Symbol{Name: name, Kind: kind, Value: unique})
+	return unique
+}
+
+// resolve возвращает имя в TAC для переменной, видимой в текущей области
+func (b *TACBuilder) resolve(name string) string {
+	if sym, ok := b.scope.Lookup(name); ok {
+		if unique, ok := sym.Value.(string); ok {
+			return unique
+		}
 	}
+	return name
 }
 
 func (b *TACBuilder) Print() {
+	b.Fprint(os.Stdout)
+}
+
+// Fprint печатает трёхадресный код в произвольный writer
+func (b *TACBuilder) Fprint(w io.Writer) {
 	for _, instr := range b.instructions {
 		switch instr.Op {
-		case "+", "-", "*", "/", "equal", "non-equal", "less", "more":
-			fmt.Printf("%s = %s %s %s\n", instr.Res, instr.Arg1, instr.Op, instr.Arg2)
+		case "+", "-", "*", "/", "%", "**", "&", "|", "^", "<<", ">>", "equal", "non-equal", "less", "more", "less-or-equal", "more-or-equal", "and", "or":
+			fmt.Fprintf(w, "%s = %s %s %s\n", instr.Res, instr.Arg1, instr.Op, instr.Arg2)
 		case "", "=":
-			fmt.Printf("%s = %s\n", instr.Res, instr.Arg1)
+			fmt.Fprintf(w, "%s = %s\n", instr.Res, instr.Arg1)
+		case "neg", "not":
+			fmt.Fprintf(w, "%s = %s %s\n", instr.Res, instr.Op, instr.Arg1)
+		case "decl", "global":
+			fmt.Fprintf(w, "%s %s %s\n", instr.Op, instr.Arg1, instr.Res)
 		case "show":
-			fmt.Printf("show %s\n", instr.Arg1)
+			fmt.Fprintf(w, "show %s\n", instr.Arg1)
 		case "goto":
-			fmt.Printf("goto %s\n", instr.Res)
+			fmt.Fprintf(w, "goto %s\n", instr.Res)
 		case "iffalse":
-			fmt.Printf("iffalse %s goto %s\n", instr.Arg1, instr.Res)
+			fmt.Fprintf(w, "iffalse %s goto %s\n", instr.Arg1, instr.Res)
 		case "label":
-			fmt.Printf("%s:\n", instr.Res)
+			fmt.Fprintf(w, "%s:\n", instr.Res)
 		case "func":
-			fmt.Printf("func %s\n", instr.Res)
+			fmt.Fprintf(w, "func %s %s\n", instr.Res, instr.Arg1)
+		case "formal":
+			fmt.Fprintf(w, "formal %s %s\n", instr.Arg1, instr.Res)
 		case "endfunc":
-			fmt.Printf("endfunc %s\n", instr.Res)
-		case "call":
-			fmt.Printf("%s = call %s with %s args\n", instr.Res, instr.Arg1, instr.Arg2)
-		default:
-			if len(instr.Op) > 3 && instr.Op[:3] == "arg" {
-				fmt.Printf("param %s\n", instr.Arg1)
+			fmt.Fprintf(w, "endfunc %s\n", instr.Res)
+		case "return":
+			if instr.Arg1 == "" {
+				fmt.Fprintln(w, "return")
 			} else {
-				fmt.Printf("// неизвестная инструкция: %+v\n", instr)
+				fmt.Fprintf(w, "return %s\n", instr.Arg1)
 			}
+		case "param", "exit":
+			fmt.Fprintf(w, "%s %s\n", instr.Op, instr.Arg1)
+		case "call":
+			fmt.Fprintf(w, "%s = call %s, %s\n", instr.Res, instr.Arg1, instr.Arg2)
+		default:
+			fmt.Fprintf(w, "// неизвестная инструкция: %+v\n", instr)
 		}
 	}
 }
@@ -251,12 +502,10 @@ func (b *TACBuilder) Optimize() {
 
 	// Проход 2: Удалим инструкции, результат которых не используется
 	for _, instr := range b.instructions {
-		isTemp := strings.HasPrefix(instr.Res, "t")
-		if instr.Res != "" &&
-			!used[instr.Res] &&
-			isTemp &&
-			instr.Op != "show" && instr.Op != "call" && instr.Op != "goto" && instr.Op != "iffalse" && instr.Op != "label" && instr.Op != "func" && instr.Op != "endfunc" {
-			continue // Удаляем только временные переменные
+		// Удаляем только временные переменные; вызов остаётся ради побочных эффектов,
+		// а деление — потому что при нулевом делителе завершает программу
+		if IsTemp(instr.Res) && !used[instr.Res] && !hasSideEffects(instr) {
+			continue
 		}
 
 		optimized = append(optimized, instr)
@@ -265,50 +514,241 @@ func (b *TACBuilder) Optimize() {
 	b.instructions = optimized
 }
 
+// hasSideEffects сообщает, что инструкцию нельзя удалить, даже если её результат не нужен
+func hasSideEffects(instr TACInstruction) bool {
+	switch instr.Op {
+	case "call":
+		return true
+	case "/", "%":
+		return instr.Type != "double"
+	}
+	return false
+}
+
+// extractConstant возвращает значение литерала в нормализованном виде:
+// целые — десятичной записью (0xFF → 255), вещественные — с точкой или экспонентой
 func extractConstant(node ast.ExpressionNode) (string, bool) {
 	switch n := node.(type) {
 	case *ast.NumberNode:
-		return n.Number.Text, true
+		value, err := lexer.ParseIntLiteral(n.Number.Text)
+		if err != nil {
+			return n.Number.Text, true
+		}
+		return strconv.FormatInt(value, 10), true
 	case *ast.FloatNode:
-		return n.Float.Text, true
+		value, err := lexer.ParseFloatLiteral(n.Float.Text)
+		if err != nil {
+			return n.Float.Text, true
+		}
+		return formatFloatConstant(value), true
 	case *ast.BooleanNode:
 		return n.Boolean.Text, true
+	case *ast.UnarOperationNode:
+		// -5 и not true — тоже константы, чтобы 2 * -3 сворачивалось целиком
+		operand, ok := extractConstant(n.Operand)
+		if !ok {
+			return "", false
+		}
+		return evalConstantUnary(unaryOp(n), operand)
+	case *ast.BinOperationNode:
+		left, ok := extractConstant(n.LeftNode)
+		if !ok {
+			return "", false
+		}
+		right, ok := extractConstant(n.RightNode)
+		if !ok {
+			return "", false
+		}
+		return evalConstantBinary(n.Operator.TypeToken.Operator(), left, right)
 	default:
 		return "", false
 	}
 }
 
-func evalConstantBinary(op, a, b string) string {
+// unaryOp возвращает имя унарной операции в TAC: neg или not
+func unaryOp(n *ast.UnarOperationNode) string {
+	if op := n.Operator.TypeToken.Operator(); op != "-" {
+		return op
+	}
+	return "neg"
+}
+
+// evalConstantUnary сворачивает унарную операцию над константой
+func evalConstantUnary(op, a string) (string, bool) {
 	switch op {
-	case "+", "-", "*", "/":
-		// Попробуем как целые
-		ai, err1 := strconv.Atoi(a)
-		bi, err2 := strconv.Atoi(b)
-		if err1 == nil && err2 == nil {
-			switch op {
-			case "+":
-				return strconv.Itoa(ai + bi)
-			case "-":
-				return strconv.Itoa(ai - bi)
-			case "*":
-				return strconv.Itoa(ai * bi)
-			case "/":
-				if bi != 0 {
-					return strconv.Itoa(ai / bi)
-				}
-				return "0" // защита от деления на ноль
-			}
+	case "neg":
+		if ai, err := strconv.ParseInt(a, 10, 64); err == nil {
+			return strconv.FormatInt(int64(int32(-ai)), 10), true
 		}
-	case "equal":
-		if a == b {
-			return "true"
+		if af, ok := parseFloatConstant(a); ok {
+			return formatFloatConstant(-af), true
+		}
+	case "not":
+		if ab, err := strconv.ParseBool(a); err == nil {
+			return strconv.FormatBool(!ab), true
 		}
-		return "false"
+	}
+	return "", false
+}
+
+// formatFloatConstant печатает вещественную константу так, чтобы её нельзя было спутать с целой
+func formatFloatConstant(value float64) string {
+	text := strconv.FormatFloat(value, 'g', -1, 64)
+	if !strings.ContainsAny(text, ".e") {
+		text += ".0"
+	}
+	return text
+}
+
+// parseFloatConstant распознаёт вещественную константу, записанную formatFloatConstant
+func parseFloatConstant(text string) (float64, bool) {
+	if !strings.ContainsAny(text, ".e") {
+		return 0, false
+	}
+	value, err := strconv.ParseFloat(text, 64)
+	return value, err == nil
+}
+
+// evalConstantBinary сворачивает операцию над двумя константами.
+// Если свернуть нельзя, второй результат false и операция остаётся в коде.
+func evalConstantBinary(op, a, b string) (string, bool) {
+	ai, errA := strconv.ParseInt(a, 10, 64)
+	bi, errB := strconv.ParseInt(b, 10, 64)
+	if errA == nil && errB == nil {
+		return evalIntBinary(op, ai, bi)
+	}
+
+	af, okA := parseFloatConstant(a)
+	bf, okB := parseFloatConstant(b)
+	if okA && okB {
+		return evalFloatBinary(op, af, bf)
+	}
+	// double ** int — единственная операция со смешанными типами
+	if op == "**" && okA && errB == nil {
+		return evalFloatBinary(op, af, float64(bi))
+	}
+
+	// Булевы константы
+	ab, errA := strconv.ParseBool(a)
+	bb, errB := strconv.ParseBool(b)
+	if errA != nil || errB != nil {
+		return "", false
+	}
+	switch op {
+	case "equal":
+		return strconv.FormatBool(ab == bb), true
 	case "non-equal":
-		if a != b {
-			return "true"
+		return strconv.FormatBool(ab != bb), true
+	case "and":
+		return strconv.FormatBool(ab && bb), true
+	case "or":
+		return strconv.FormatBool(ab || bb), true
+	}
+	return "", false
+}
+
+// evalIntBinary вычисляет операцию над int с переполнением как у i32 в LLVM
+func evalIntBinary(op string, a, b int64) (string, bool) {
+	wrap := func(v int64) (string, bool) {
+		return strconv.FormatInt(int64(int32(v)), 10), true
+	}
+	switch op {
+	case "+":
+		return wrap(a + b)
+	case "-":
+		return wrap(a - b)
+	case "*":
+		return wrap(a * b)
+	case "/", "%":
+		// Деление на ноль остаётся в коде и завершает программу при выполнении
+		if b == 0 {
+			return "", false
+		}
+		if op == "/" {
+			return wrap(a / b)
 		}
-		return "false"
+		return wrap(a % b)
+	case "**":
+		// Отрицательный показатель, как в интерпретаторе и llvmgen: 1 / a**-b
+		// усекается к нулю, кроме a = 1 и a = -1
+		if b < 0 {
+			switch {
+			case a == 1:
+				return "1", true
+			case a == -1 && b%2 == 0:
+				return "1", true
+			case a == -1:
+				return "-1", true
+			}
+			return "0", true
+		}
+		// Возведение в квадрат с переполнением i32, как в llvmgen; при b = 0 результат 1
+		result, base := int32(1), int32(a)
+		for ; b > 0; b >>= 1 {
+			if b&1 == 1 {
+				result *= base
+			}
+			base *= base
+		}
+		return wrap(int64(result))
+	case "&":
+		return wrap(a & b)
+	case "|":
+		return wrap(a | b)
+	case "^":
+		return wrap(a ^ b)
+	case "<<":
+		// Величина сдвига берётся по модулю 32, как и в сгенерированном коде
+		return wrap(int64(int32(a) << (b & 31)))
+	case ">>":
+		return wrap(int64(int32(a) >> (b & 31)))
+	case "equal":
+		return strconv.FormatBool(a == b), true
+	case "non-equal":
+		return strconv.FormatBool(a != b), true
+	case "less":
+		return strconv.FormatBool(a < b), true
+	case "more":
+		return strconv.FormatBool(a > b), true
+	case "less-or-equal":
+		return strconv.FormatBool(a <= b), true
+	case "more-or-equal":
+		return strconv.FormatBool(a >= b), true
+	}
+	return "", false
+}
+
+func evalFloatBinary(op string, a, b float64) (string, bool) {
+	var result float64
+	switch op {
+	case "+":
+		result = a + b
+	case "-":
+		result = a - b
+	case "*":
+		result = a * b
+	case "/":
+		result = a / b
+	case "**":
+		result = math.Pow(a, b)
+	case "equal":
+		return strconv.FormatBool(a == b), true
+	case "non-equal":
+		return strconv.FormatBool(a != b), true
+	case "less":
+		return strconv.FormatBool(a < b), true
+	case "more":
+		return strconv.FormatBool(a > b), true
+	case "less-or-equal":
+		return strconv.FormatBool(a <= b), true
+	case "more-or-equal":
+		return strconv.FormatBool(a >= b), true
+	default:
+		return "", false
+	}
+	// Бесконечность и NaN оставляем вычислять во время выполнения
+	if math.IsInf(result, 0) || math.IsNaN(result) {
+		return "", false
 	}
-	return "0"
+	return formatFloatConstant(result), true
 }
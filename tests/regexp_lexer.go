@@ -0,0 +1,61 @@
+package tests
+
+import (
+	"compiler_project/diagnostics"
+	"compiler_project/lexer"
+	"regexp"
+	"unicode/utf8"
+)
+
+// regexpLexer — прежняя реализация лексера на регулярных выражениях из
+// lexer.TokenTypesOrdered. Только для тестов: с ней сравниваются поток токенов
+// и скорость lexer.Lexer на программах без расхождений (см. комментарий к lexer.Lexer).
+type regexpLexer struct {
+	code        string
+	pos         int
+	Tokens      []lexer.Token
+	Diagnostics diagnostics.List
+}
+
+func newRegexpLexer(code string) *regexpLexer {
+	return &regexpLexer{code: code}
+}
+
+func (l *regexpLexer) LexerAnalysis() *[]lexer.Token {
+	for l.nextToken() {
+	}
+
+	return &l.Tokens
+}
+
+func (l *regexpLexer) nextToken() bool {
+	if l.pos >= len(l.code) {
+		return false
+	}
+
+	for _, tokenType := range lexer.TokenTypesOrdered {
+		regex, err := regexp.Compile("^" + tokenType.Regex)
+		if err != nil {
+			panic(err)
+		}
+		str := l.code[l.pos:]
+		match := regex.FindString(str)
+		if match != "" {
+			token := lexer.NewToken(tokenType, match, l.pos)
+			if token.TypeToken.Name != "WHITESPACE" {
+				l.Tokens = append(l.Tokens, *token)
+			}
+			l.pos += len(match)
+			return true
+		}
+	}
+
+	_, size := utf8.DecodeRuneInString(l.code[l.pos:])
+	l.Diagnostics.Add(diagnostics.Errorf(
+		diagnostics.Span{Start: l.pos, End: l.pos + size},
+		diagnostics.CodeUnexpectedChar,
+		"неожиданный символ %q", l.code[l.pos:l.pos+size],
+	))
+	l.pos += size
+	return true
+}
@@ -0,0 +1,66 @@
+package tests
+
+import (
+	"bytes"
+	"compiler_project/backend"
+	"compiler_project/diagnostics"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// TestNativeBuild собирает программу найденными инструментами и запускает её.
+// Без clang или llc с cc тест пропускается.
+func TestNativeBuild(t *testing.T) {
+	tc, ok := backend.FindToolchain()
+	if !ok {
+		t.Skip("не найден clang или llc с cc")
+	}
+	ir := buildLLVM(t, `
+		func fact(int n) int { if n <= 1 { return 1; }; return n * fact(n - 1); };
+		show fact(10);
+		show "done";
+		exit(3);
+	`)
+	dir := t.TempDir()
+
+	for _, level := range []int{0, 2} {
+		exe := filepath.Join(dir, "prog")
+		if err := tc.Build(ir, backend.Config{OptLevel: level, Output: exe}); err != nil {
+			t.Fatalf("-O%d: сборка через %s: %v", level, tc, err)
+		}
+		out, err := exec.Command(exe).Output()
+		exitErr, isExit := err.(*exec.ExitError)
+		if !isExit || exitErr.ExitCode() != 3 {
+			t.Errorf("-O%d: ожидался код завершения 3, получено %v", level, err)
+		}
+		if want := ">> 3628800\n>> done\n"; string(out) != want {
+			t.Errorf("-O%d: программа напечатала %q, ожидалось %q", level, out, want)
+		}
+	}
+
+	object := filepath.Join(dir, "prog.o")
+	if err := tc.Build(ir, backend.Config{Object: true, Output: object}); err != nil {
+		t.Fatalf("-c: %v", err)
+	}
+	data, err := os.ReadFile(object)
+	if err != nil || !bytes.HasPrefix(data, []byte("\x7fELF")) {
+		t.Errorf("-c: ожидался объектный файл ELF, получено %v", err)
+	}
+
+	// Ошибка инструмента доходит до драйвера диагностикой с его выводом
+	err = tc.Build("это не LLVM IR", backend.Config{Output: filepath.Join(dir, "bad")})
+	d, ok := err.(diagnostics.Diagnostic)
+	if !ok || d.Code != diagnostics.CodeToolchainFailed || len(d.Notes) == 0 {
+		t.Errorf("ожидалась ошибка %s с выводом инструмента, получено %#v", diagnostics.CodeToolchainFailed, err)
+	}
+}
+
+// TestMissingToolchain — без инструментов в PATH сборка невозможна
+func TestMissingToolchain(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	if tc, ok := backend.FindToolchain(); ok {
+		t.Errorf("найдены инструменты в пустом PATH: %+v", tc)
+	}
+}
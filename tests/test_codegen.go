@@ -1 +1,585 @@
 package tests
+
+import (
+	"compiler_project/diagnostics"
+	"compiler_project/interp"
+	"compiler_project/lexer"
+	"compiler_project/llvmgen"
+	"compiler_project/parser"
+	"compiler_project/semantics"
+	"compiler_project/tac"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// buildTAC строит трёхадресный код программы и возвращает его текст
+func buildTAC(t *testing.T, code string) string {
+	t.Helper()
+	l := lexer.NewLexer(code)
+	p := parser.NewParser(*l.LexerAnalysis())
+	root := p.ParseCode()
+	if len(l.Diagnostics) > 0 || len(p.Diagnostics) > 0 {
+		t.Fatalf("%q: неожиданные синтаксические ошибки: %v %v", code, l.Diagnostics, p.Diagnostics)
+	}
+	builder := tac.NewTACBuilder()
+	builder.Generate(root)
+	var sb strings.Builder
+	builder.Fprint(&sb)
+	return sb.String()
+}
+
+// TestConstantFolding — свёртка констант совпадает с семантикой i32 в LLVM
+func TestConstantFolding(t *testing.T) {
+	for _, tc := range []struct{ expr, want string }{
+		{"2 ** 10", "1024"},
+		{"2 ** 31", "-2147483648"},
+		{"3 ** 0", "1"},
+		{"2 ** -1", "0"},
+		{"0 ** -1", "0"},
+		{"1 ** -5", "1"},
+		{"(-1) ** -3", "-1"},
+		{"(-1) ** -4", "1"},
+		{"-17 % 5", "-2"},
+		{"7 & 3 | 8", "11"},
+		{"5 ^ 1", "4"},
+		{"1 << 33", "2"},
+		{"-64 >> 2", "-16"},
+		{"2.0 ** 3", "8.0"},
+		{"4.0 ** 0.5", "2.0"},
+	} {
+		got := buildTAC(t, "x = "+tc.expr+";")
+		if want := "x = " + tc.want + "\n"; got != want {
+			t.Errorf("%s: получено %q, ожидалось %q", tc.expr, got, want)
+		}
+	}
+}
+
+// TestShortCircuitTAC — and/or записываются переходами вокруг правого операнда
+func TestShortCircuitTAC(t *testing.T) {
+	tests := []struct {
+		code string
+		want []string
+	}{
+		{"r = a && b;", []string{
+			"decl boolean $t1", "$t1 = a", "iffalse $t1 goto L1", "$t1 = b", "L1:", "r = $t1",
+		}},
+		{"r = a or b;", []string{
+			"decl boolean $t1", "$t1 = a", "iffalse $t1 goto L1", "goto L2", "L1:", "$t1 = b", "L2:", "r = $t1",
+		}},
+	}
+	for _, tt := range tests {
+		if got, want := buildTAC(t, tt.code), strings.Join(tt.want, "\n")+"\n"; got != want {
+			t.Errorf("%s: получено\n%s\nожидалось\n%s", tt.code, got, want)
+		}
+	}
+}
+
+// TestDivisionByZero — деление на ноль не сворачивается в константу,
+// а перед sdiv/srem с неизвестным делителем стоит проверка
+func TestDivisionByZero(t *testing.T) {
+	for _, op := range []string{"/", "%"} {
+		code := "x = 7 " + op + " 0;"
+		if got, want := buildTAC(t, code), "$t1 = 7 "+op+" 0\nx = $t1\n"; got != want {
+			t.Errorf("%s: получено %q, ожидалось %q", code, got, want)
+		}
+	}
+
+	ir := buildLLVM(t, `int a = 7; int z = 0; int q = a / z; int r = a % 2; show q + r;`)
+	if n := strings.Count(ir, "call void @zero.division()"); n != 1 {
+		t.Errorf("ожидалась одна проверка делителя, найдено %d:\n%s", n, ir)
+	}
+	for _, want := range []string{"icmp eq i32", "define internal void @zero.division()", "@dprintf(i32 2,",
+		fmt.Sprintf("call void @exit(i32 %d)", llvmgen.ZeroDivisionStatus)} {
+		if !strings.Contains(ir, want) {
+			t.Errorf("в IR нет %q:\n%s", want, ir)
+		}
+	}
+}
+
+// buildLLVM прогоняет весь конвейер до LLVM IR и возвращает текст модуля
+func buildLLVM(t *testing.T, code string) string {
+	t.Helper()
+	l := lexer.NewLexer(code)
+	p := parser.NewParser(*l.LexerAnalysis())
+	root := p.ParseCode()
+	if len(l.Diagnostics) > 0 || len(p.Diagnostics) > 0 {
+		t.Fatalf("%q: неожиданные синтаксические ошибки: %v %v", code, l.Diagnostics, p.Diagnostics)
+	}
+	checker := semantics.NewTypeChecker()
+	if _, err := checker.Check(root); err != nil {
+		t.Fatalf("%q: неожиданная семантическая ошибка: %v", code, err)
+	}
+	builder := tac.NewTACBuilder()
+	builder.SetTypes(checker.Types)
+	builder.Generate(root)
+	builder.Optimize()
+	gen := llvmgen.NewLLVMBuilder(llvmgen.Options{})
+	gen.GenerateFromTAC(builder.Instructions())
+	if len(gen.Diagnostics) > 0 {
+		t.Fatalf("%q: ошибки генерации: %v", code, gen.Diagnostics)
+	}
+	return gen.IR().String()
+}
+
+// TestFunctionDefinitions — каждая функция определяется один раз со своими
+// параметрами, а все вызовы ссылаются на это определение
+func TestFunctionDefinitions(t *testing.T) {
+	ir := buildLLVM(t, `
+		func add(int a, int b) int { return a + b; };
+		func greet(string who) { show who; };
+		int x = add(1, 2);
+		int y = add(x, 3);
+		greet("hi");
+	`)
+	for _, want := range []string{
+		"define i32 @add(i32 %p.a, i32 %p.b)",
+		"define void @greet(i8* %p.who)",
+		"call i32 @add(i32 1, i32 2)",
+		"call void @greet(",
+	} {
+		if !strings.Contains(ir, want) {
+			t.Errorf("в IR нет %q:\n%s", want, ir)
+		}
+	}
+	if n := strings.Count(ir, "@add("); n != 3 {
+		t.Errorf("@add встречается %d раз, ожидалось 3 (определение и два вызова):\n%s", n, ir)
+	}
+	if strings.Contains(ir, "declare i32 @add") {
+		t.Errorf("у add не должно быть отдельного объявления:\n%s", ir)
+	}
+}
+
+// TestCallParams — param идут перед call, вложенные вызовы полностью
+// вычисляются до передачи аргументов внешнему
+func TestCallParams(t *testing.T) {
+	got := buildTAC(t, "x = f(g(1), 2);")
+	want := strings.Join([]string{
+		"param 1",
+		"$t1 = call g, 1",
+		"param $t1",
+		"param 2",
+		"$t2 = call f, 2",
+		"x = $t2",
+	}, "\n") + "\n"
+	if got != want {
+		t.Errorf("получено:\n%s\nожидалось:\n%s", got, want)
+	}
+}
+
+// TestOptimizeKeepsUserVariables — переменная с именем вида t1 не считается
+// временной, и её присваивание не удаляется
+func TestOptimizeKeepsUserVariables(t *testing.T) {
+	l := lexer.NewLexer("int t1 = 1; int total = t1 + 2;")
+	p := parser.NewParser(*l.LexerAnalysis())
+	builder := tac.NewTACBuilder()
+	builder.Generate(p.ParseCode())
+	builder.Optimize()
+	var sb strings.Builder
+	builder.Fprint(&sb)
+	for _, want := range []string{"t1 = 1\n", "total = $t1\n"} {
+		if !strings.Contains(sb.String(), want) {
+			t.Errorf("после Optimize нет %q:\n%s", want, sb.String())
+		}
+	}
+}
+
+// TestShadowedVariables — скрывающая переменная получает в TAC своё имя,
+// а переменные верхнего уровня объявляются глобальными
+func TestShadowedVariables(t *testing.T) {
+	got := buildTAC(t, "int x = 1; if true { int x = 2; show x; }; show x;")
+	for _, want := range []string{"global int x\n", "decl int x.1\n", "show x.1\n", "show x\n"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("нет %q:\n%s", want, got)
+		}
+	}
+}
+
+// TestGlobalsInFunctions — функция читает и меняет переменную верхнего уровня
+func TestGlobalsInFunctions(t *testing.T) {
+	ir := buildLLVM(t, "int total = 0; func add(int n) { total = total + n; }; add(5);")
+	for _, want := range []string{"@g.total = global i32 0", "load i32, i32* @g.total", "store i32 %"} {
+		if !strings.Contains(ir, want) {
+			t.Errorf("нет %q:\n%s", want, ir)
+		}
+	}
+}
+
+// TestTypedTAC — инструкции несут типы из checker
+func TestTypedTAC(t *testing.T) {
+	l := lexer.NewLexer("func half(double v) double { return v / 2.0; }; double x = half(3.0) + 1.5; boolean b = x < 2.0;")
+	p := parser.NewParser(*l.LexerAnalysis())
+	root := p.ParseCode()
+	checker := semantics.NewTypeChecker()
+	if _, err := checker.Check(root); err != nil {
+		t.Fatalf("неожиданная семантическая ошибка: %v", err)
+	}
+	builder := tac.NewTACBuilder()
+	builder.SetTypes(checker.Types)
+	builder.Generate(root)
+
+	want := map[string]string{"/": "double", "return": "double", "param": "double", "call": "double", "+": "double", "less": "boolean"}
+	for _, instr := range builder.Instructions() {
+		if typ, ok := want[instr.Op]; ok && instr.Type != typ {
+			t.Errorf("%+v: тип %q, ожидался %q", instr, instr.Type, typ)
+		}
+	}
+}
+
+// TestTypedCodegen — вещественные числа используют double и fadd/fcmp,
+// логические — i1, строки — i8* и сравниваются по содержимому
+func TestTypedCodegen(t *testing.T) {
+	ir := buildLLVM(t, `
+		double x = 1.5;
+		double y = x * 2.0 + 0.25;
+		boolean b = x < y;
+		string s = "hi";
+		boolean same = s == "hi";
+	`)
+	for _, want := range []string{
+		"@g.x = global double 0.0",
+		"fmul double",
+		"fadd double",
+		"fcmp olt double",
+		"@g.b = global i1 false",
+		"@g.s = global i8* null",
+		"call i32 @strcmp(",
+		"icmp eq i32",
+	} {
+		if !strings.Contains(ir, want) {
+			t.Errorf("в IR нет %q:\n%s", want, ir)
+		}
+	}
+}
+
+// TestUnaryCodegen — унарный минус становится neg (sub i32 0 или fneg),
+// отрицание — not (xor i1 с true)
+func TestUnaryCodegen(t *testing.T) {
+	code := "int a = 5; double d = 1.5; boolean b = true; int n = -a; double m = -d; boolean c = !b; boolean e = not c;"
+	tacText := buildTAC(t, code)
+	for _, want := range []string{"$t1 = neg a\n", "$t2 = neg d\n", "$t3 = not b\n", "$t4 = not c\n"} {
+		if !strings.Contains(tacText, want) {
+			t.Errorf("в TAC нет %q:\n%s", want, tacText)
+		}
+	}
+
+	ir := buildLLVM(t, code)
+	for _, want := range []*regexp.Regexp{
+		regexp.MustCompile(`= sub i32 0, %\d+\n`),
+		regexp.MustCompile(`= fneg double %\d+\n`),
+		regexp.MustCompile(`(?s)= xor i1 %\d+, true\n.*= xor i1 %\d+, true\n`),
+	} {
+		if !want.MatchString(ir) {
+			t.Errorf("в IR нет %s:\n%s", want, ir)
+		}
+	}
+}
+
+// TestComparisonPredicates — целые сравниваются со знаком (icmp s*),
+// вещественные — упорядоченно (fcmp o*), кроме != (une, истинно для NaN);
+// && и || становятся переходами, а не and/or над i1
+func TestComparisonPredicates(t *testing.T) {
+	ir := buildLLVM(t, `
+		int a = 1; int b = 2;
+		double x = 1.5; double y = 2.5;
+		boolean c1 = a <= b; boolean c2 = a >= b; boolean c3 = a == b; boolean c4 = a != b;
+		boolean c5 = a < b; boolean c6 = a > b;
+		boolean d1 = x <= y; boolean d2 = x >= y; boolean d3 = x == y; boolean d4 = x != y;
+		boolean d5 = x < y; boolean d6 = x > y;
+		boolean l = c1 && c2 || !c3;
+		boolean w = c1 and c2 or not c3;
+	`)
+	for _, want := range []string{
+		"icmp sle i32", "icmp sge i32", "icmp eq i32", "icmp ne i32", "icmp slt i32", "icmp sgt i32",
+		"fcmp ole double", "fcmp oge double", "fcmp oeq double", "fcmp une double", "fcmp olt double", "fcmp ogt double",
+		"xor i1", "br i1",
+	} {
+		if !strings.Contains(ir, want) {
+			t.Errorf("в IR нет %q:\n%s", want, ir)
+		}
+	}
+	if regexp.MustCompile(`= (and|or) i1`).MatchString(ir) {
+		t.Errorf("&& и || вычисляют оба операнда:\n%s", ir)
+	}
+	// Строки сравниваются по содержимому, а не по адресу
+	ir = buildLLVM(t, `string s = "a"; string r = "a"; boolean e = s == r; boolean n = s != r;`)
+	if strings.Contains(ir, "icmp eq i8*") || strings.Contains(ir, "icmp ne i8*") {
+		t.Errorf("строки сравниваются как указатели:\n%s", ir)
+	}
+	if strings.Count(ir, "call i32 @strcmp(") != 2 {
+		t.Errorf("ожидалось два вызова strcmp:\n%s", ir)
+	}
+}
+
+// TestStringConstants — строки попадают в IR декодированными байтами с завершающим
+// нулём, а длина массива учитывает каждый байт UTF-8 и этот нуль
+func TestStringConstants(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+	}{
+		{`string s = "a\tb"; show s;`, `[4 x i8] c"a\09b\00"`},
+		{`string s = ""; show s;`, `[1 x i8] c"\00"`},
+		{`string s = "\"\\"; show s;`, `[3 x i8] c"\22\5C\00"`},
+		{`string s = "да"; show s;`, `[5 x i8] c"\D0\B4\D0\B0\00"`},
+	}
+	for _, tt := range tests {
+		ir := buildLLVM(t, tt.code)
+		if !strings.Contains(ir, "constant "+tt.want) {
+			t.Errorf("%q: в IR нет константы %s:\n%s", tt.code, tt.want, ir)
+		}
+		// Указатель на строку берётся из массива той же длины
+		arr := tt.want[:strings.Index(tt.want, "]")+1]
+		if !strings.Contains(ir, "getelementptr ("+arr+", "+arr+"* @.str.") {
+			t.Errorf("%q: нет getelementptr по %s:\n%s", tt.code, arr, ir)
+		}
+	}
+}
+
+// TestLoweringUsesTACTypes — целая или вещественная арифметика выбирается
+// по типу из TAC; без типов решает тип значения
+func TestLoweringUsesTACTypes(t *testing.T) {
+	lower := func(typ string) *llvmgen.LLVMBuilder {
+		gen := llvmgen.NewLLVMBuilder(llvmgen.Options{})
+		gen.GenerateFromTAC([]tac.TACInstruction{
+			{Op: "+", Arg1: "1.5", Arg2: "2.5", Res: "$t1", Type: typ},
+			{Op: "neg", Arg1: "$t1", Res: "$t2", Type: typ},
+			{Op: "show", Arg1: "$t2"},
+		})
+		return gen
+	}
+
+	for _, typ := range []string{"double", ""} {
+		gen := lower(typ)
+		ir := gen.IR().String()
+		if len(gen.Diagnostics) > 0 || !strings.Contains(ir, "fadd double") || !strings.Contains(ir, "fneg double") {
+			t.Errorf("тип %q: ожидались fadd и fneg без ошибок: %v\n%s", typ, gen.Diagnostics, ir)
+		}
+	}
+
+	// Тип из TAC расходится со значениями: целая операция над double даёт ошибку
+	gen := lower("int")
+	if len(gen.Diagnostics) == 0 || gen.Diagnostics[0].Code != diagnostics.CodeUnsupportedType {
+		t.Errorf("ожидалась ошибка %s, получено %v", diagnostics.CodeUnsupportedType, gen.Diagnostics)
+	}
+}
+
+// nameClashProgram использует имена, которые модуль мог бы занять сам:
+// параметры совпадают с метками блоков, функции — с форматами show
+const nameClashProgram = `
+	func f(int entry, int L1, int continue_3) { if entry > 0 { show L1 + continue_3; }; };
+	f(1, 2, 3);
+	func fmt() { show "fmt"; };
+	func str_1(string s) string { return s; };
+	fmt(); show 2; show str_1("str"); show 1.5;
+`
+
+// TestInternalNames — параметры и служебные глобальные имена модуля не совпадают
+// с пользовательскими именами
+func TestInternalNames(t *testing.T) {
+	ir := buildLLVM(t, nameClashProgram)
+	for _, want := range []string{
+		"define void @f(i32 %p.entry, i32 %p.L1, i32 %p.continue_3)",
+		"define void @fmt()",
+		"define i8* @str_1(i8* %p.s)",
+		"@.fmt.int = ", "@.fmt.str = ", "@.fmt.double = ",
+	} {
+		if !strings.Contains(ir, want) {
+			t.Errorf("в IR нет %q:\n%s", want, ir)
+		}
+	}
+}
+
+// differentialPrograms покрывают show для всех типов; вывод интерпретатора
+// и скомпилированной программы должен совпадать побайтно
+var differentialPrograms = []string{
+	`show 42; show -7; show 2 ** 31; show 7 % 3;`,
+	`int two = 2; int m1 = -1; int e = -1; show two ** e; show two ** -31; show m1 ** -3; show m1 ** -4; show 1 ** e; show two ** 0;`,
+	`int m = -2147483648; show m; show m - 1; show -2147483648 + 1;`,
+	`show 1.5; show 0.1 + 0.2; show 1.0 / 3.0; show 1e21; show 100000.0; show 1234567.0; show 0.00001;`,
+	`double zero = 0.0; show 1.0 / zero; show -1.0 / zero; show zero / zero;`,
+	`show true; show false; show 1 < 2; show not (1.5 > 2.5);`,
+	`show "hello"; string s = "a\tb"; show s; show s == "a\tb";`,
+	`func half(double v) double { return v / 2.0; };
+	 func even(int n) boolean { return n % 2 == 0; };
+	 func name(boolean b) string { if b { return "yes"; }; return "no"; };
+	 show half(5.0); show even(4); show name(even(3));`,
+	shortCircuitProgram,
+	nameClashProgram,
+	`int z = 0; show 1; show 7 % 2; show 10 / z; show 3;`,
+	`show 1; show 7 / 0;`,
+	`int z = 0; func f(int d) int { return 1 % d; }; show f(1); f(z); show 2;`,
+}
+
+// TestShowDifferential сравнивает вывод и код завершения интерпретатора и lli.
+// Деление на ноль обе стороны завершают ошибкой R0005. Без LLVM тест пропускается.
+func TestShowDifferential(t *testing.T) {
+	lli, err := exec.LookPath("lli")
+	if err != nil {
+		t.Skip("lli не найден в PATH")
+	}
+	for _, code := range differentialPrograms {
+		ir := buildLLVM(t, code)
+
+		l := lexer.NewLexer(code)
+		p := parser.NewParser(*l.LexerAnalysis())
+		var want strings.Builder
+		in := interp.New(&want)
+		err := in.Run(p.ParseCode())
+		wantCode := in.ExitCode()
+		if err != nil {
+			if d, ok := err.(diagnostics.Diagnostic); !ok || d.Code != diagnostics.CodeZeroDivision {
+				t.Fatalf("%q: ошибка интерпретатора: %v", code, err)
+			}
+			wantCode = llvmgen.ZeroDivisionStatus
+		}
+
+		var stderr strings.Builder
+		cmd := exec.Command(lli)
+		cmd.Stdin = strings.NewReader(ir)
+		cmd.Stderr = &stderr
+		got, err := cmd.Output()
+		gotCode := 0
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			gotCode = exitErr.ExitCode()
+		} else if err != nil {
+			t.Fatalf("%q: lli: %v", code, err)
+		}
+		if string(got) != want.String() {
+			t.Errorf("%q: скомпилированная программа напечатала:\n%s\nинтерпретатор:\n%s", code, got, want.String())
+		}
+		if gotCode != wantCode {
+			t.Errorf("%q: код завершения %d, у интерпретатора %d\n%s", code, gotCode, wantCode, stderr.String())
+		}
+		if wantCode == llvmgen.ZeroDivisionStatus && !strings.Contains(stderr.String(), "error["+diagnostics.CodeZeroDivision+"]") {
+			t.Errorf("%q: в stderr нет ошибки %s: %q", code, diagnostics.CodeZeroDivision, stderr.String())
+		}
+	}
+}
+
+// TestProgramEpilogue — по умолчанию main просто возвращает код,
+// ожидание клавиши появляется только по WaitForKey
+func TestProgramEpilogue(t *testing.T) {
+	ir := buildLLVM(t, "show 1;")
+	for _, unwanted := range []string{"system", "pause", "getchar"} {
+		if strings.Contains(ir, unwanted) {
+			t.Errorf("в IR по умолчанию есть %q:\n%s", unwanted, ir)
+		}
+	}
+	if !strings.Contains(ir, "ret i32 0") {
+		t.Errorf("main не возвращает 0:\n%s", ir)
+	}
+
+	l := lexer.NewLexer("show 1;")
+	p := parser.NewParser(*l.LexerAnalysis())
+	builder := tac.NewTACBuilder()
+	builder.Generate(p.ParseCode())
+	gen := llvmgen.NewLLVMBuilder(llvmgen.Options{WaitForKey: true})
+	gen.GenerateFromTAC(builder.Instructions())
+	if ir := gen.IR().String(); !strings.Contains(ir, "call i32 @getchar()") {
+		t.Errorf("WaitForKey не добавил getchar:\n%s", ir)
+	}
+}
+
+// TestExitStatus — exit(code) из main и из функции задаёт код завершения
+// и скомпилированной программы, и интерпретатора
+func TestExitStatus(t *testing.T) {
+	lli, err := exec.LookPath("lli")
+	if err != nil {
+		t.Skip("lli не найден в PATH")
+	}
+	for _, tc := range []struct {
+		code string
+		want int
+	}{
+		{"show 1;", 0},
+		{"int x = 4; if x > 3 { exit(x * 10); }; show 99;", 40},
+		{"func check(int v) { if v > 2 { exit(v); }; }; int i = 0; while i < 10 { check(i); i = i + 1; };", 3},
+	} {
+		ir := buildLLVM(t, tc.code)
+		cmd := exec.Command(lli)
+		cmd.Stdin = strings.NewReader(ir)
+		got := 0
+		if err := cmd.Run(); err != nil {
+			exitErr, ok := err.(*exec.ExitError)
+			if !ok {
+				t.Fatalf("%q: lli: %v", tc.code, err)
+			}
+			got = exitErr.ExitCode()
+		}
+		if got != tc.want {
+			t.Errorf("%q: код завершения %d, ожидался %d", tc.code, got, tc.want)
+		}
+
+		in, _, err := runProgram(t, tc.code, interp.DefaultMaxCallDepth)
+		if err != nil || in.ExitCode() != tc.want {
+			t.Errorf("%q: интерпретатор завершился с кодом %d (%v), ожидался %d", tc.code, in.ExitCode(), err, tc.want)
+		}
+	}
+}
+
+// TestTargets — модуль несёт триплет, раскладку данных и имя исходного файла
+func TestTargets(t *testing.T) {
+	ir := buildLLVM(t, "show 1;")
+	if !strings.Contains(ir, `target triple = "`+llvmgen.DefaultTarget+`"`) || !strings.Contains(ir, "target datalayout = ") {
+		t.Errorf("в IR нет целевой платформы по умолчанию:\n%s", ir)
+	}
+
+	for _, triple := range llvmgen.Targets() {
+		target, _ := llvmgen.LookupTarget(triple)
+		gen := llvmgen.NewLLVMBuilder(llvmgen.Options{Target: triple, SourceFilename: "prog.src"})
+		gen.GenerateFromTAC(nil)
+		ir := gen.IR().String()
+		for _, want := range []string{
+			`source_filename = "prog.src"`,
+			`target triple = "` + triple + `"`,
+			`target datalayout = "` + target.DataLayout + `"`,
+		} {
+			if !strings.Contains(ir, want) {
+				t.Errorf("%s: в IR нет %q:\n%s", triple, want, ir)
+			}
+		}
+	}
+
+	gen := llvmgen.NewLLVMBuilder(llvmgen.Options{Target: "pdp11-dec-unix"})
+	if len(gen.Diagnostics) != 1 || gen.Diagnostics[0].Code != diagnostics.CodeUnknownTarget {
+		t.Errorf("неизвестная платформа: %v", gen.Diagnostics)
+	}
+}
+
+// TestDataLayouts сверяет раскладки данных с теми, что выбирает сам llc.
+// Тест пропускается без llc или если его версия не DataLayoutLLVMVersion.
+func TestDataLayouts(t *testing.T) {
+	llc, err := exec.LookPath("llc")
+	if err != nil {
+		t.Skip("llc не найден в PATH")
+	}
+	version, err := exec.Command(llc, "--version").Output()
+	if err != nil {
+		t.Fatalf("llc --version: %v", err)
+	}
+	if want := fmt.Sprintf("LLVM version %d.", llvmgen.DataLayoutLLVMVersion); !strings.Contains(string(version), want) {
+		t.Skipf("раскладки записаны для LLVM %d, установлен другой llc:\n%s", llvmgen.DataLayoutLLVMVersion, version)
+	}
+
+	layout := regexp.MustCompile(`target datalayout = "([^"]*)"`)
+	for _, triple := range llvmgen.Targets() {
+		target, _ := llvmgen.LookupTarget(triple)
+		// Модуль без раскладки: llc подставляет свою и печатает её вместе с модулем
+		cmd := exec.Command(llc, "-mtriple="+triple, "-print-after=verify", "-print-module-scope", "-filetype=null", "-o", "-")
+		cmd.Stdin = strings.NewReader("define i32 @main() {\n  ret i32 0\n}\n")
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("%s: llc: %v\n%s", triple, err, out)
+		}
+		m := layout.FindSubmatch(out)
+		if m == nil {
+			t.Fatalf("%s: llc не напечатал раскладку:\n%s", triple, out)
+		}
+		if string(m[1]) != target.DataLayout {
+			t.Errorf("%s: раскладка %q, llc выбирает %q", triple, target.DataLayout, m[1])
+		}
+	}
+}
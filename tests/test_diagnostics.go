@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"compiler_project/diagnostics"
+	"strings"
+	"testing"
+)
+
+// TestSourcePositions — строки и столбцы считаются с единицы, столбец — в символах,
+// поэтому кириллица не сдвигает позицию
+func TestSourcePositions(t *testing.T) {
+	text := "int x = 1;\nstring имя = \"да\";\r\n\tshow имя;"
+	f := diagnostics.NewFile("a.src", text)
+
+	tests := []struct {
+		needle    string
+		line, col int
+	}{
+		{"int", 1, 1},
+		{"x", 1, 5},
+		{"string", 2, 1},
+		{"= \"да", 2, 12},
+		{"\"да\"", 2, 14},
+		{";\r", 2, 18},
+		{"show", 3, 2},
+		{"имя;", 3, 7},
+	}
+	for _, tt := range tests {
+		line, col := f.Position(strings.Index(text, tt.needle))
+		if line != tt.line || col != tt.col {
+			t.Errorf("%q: позиция %d:%d, ожидалась %d:%d", tt.needle, line, col, tt.line, tt.col)
+		}
+	}
+	if line, col := f.Position(len(text) + 10); line != 3 || col != 11 {
+		t.Errorf("смещение за концом файла: %d:%d, ожидалось 3:11", line, col)
+	}
+
+	for i, want := range []string{"int x = 1;", "string имя = \"да\";", "\tshow имя;"} {
+		if got := f.Line(i + 1); got != want {
+			t.Errorf("строка %d: %q, ожидалась %q", i+1, got, want)
+		}
+	}
+	if got := f.Line(4); got != "" {
+		t.Errorf("несуществующая строка: %q", got)
+	}
+}
+
+// TestRenderUnderline — подчёркивание начинается под участком, сохраняет табы
+// и имеет ширину участка в символах, но не выходит за конец строки
+func TestRenderUnderline(t *testing.T) {
+	text := "string имя = 1;\n\tshow имя;\nint y = 2;"
+	f := diagnostics.NewFile("a.src", text)
+	span := func(needle string, length int) diagnostics.Span {
+		start := strings.Index(text, needle)
+		return diagnostics.Span{Start: start, End: start + length}
+	}
+
+	tests := []struct {
+		span diagnostics.Span
+		want string
+	}{
+		{span("имя = 1", len("имя")),
+			"a.src:1:8: error[S0002]: ошибка\n 1 | string имя = 1;\n   |        ^~~\n"},
+		{span("имя;", len("имя")),
+			"a.src:2:7: error[S0002]: ошибка\n 2 | \tshow имя;\n   | \t     ^~~\n"},
+		// Пустой участок подчёркивается одним символом
+		{span("y", 0),
+			"a.src:3:5: error[S0002]: ошибка\n 3 | int y = 2;\n   |     ^\n"},
+		// Участок до конца файла обрезается по концу строки
+		{span("show", len(text)-strings.Index(text, "show")),
+			"a.src:2:2: error[S0002]: ошибка\n 2 | \tshow имя;\n   | \t^~~~~~~~~\n"},
+	}
+	for _, tt := range tests {
+		var sb strings.Builder
+		diagnostics.Render(&sb, f, diagnostics.Errorf(tt.span, diagnostics.CodeTypeMismatch, "ошибка"))
+		if sb.String() != tt.want {
+			t.Errorf("получено:\n%s\nожидалось:\n%s", sb.String(), tt.want)
+		}
+	}
+}
+
+// TestRenderNotesAndRelated — примечания печатаются под подчёркиванием,
+// связанные места — отдельными диагностиками из своих файлов
+func TestRenderNotesAndRelated(t *testing.T) {
+	files := diagnostics.NewFileSet()
+	first := files.AddFile("a.src", "int x = 1;")
+	second := files.AddFile("b.src", "\nint x = 2;")
+
+	at := func(f *diagnostics.File, offset, length int) diagnostics.Span {
+		return diagnostics.Span{Start: f.Base + offset, End: f.Base + offset + length}
+	}
+	d := diagnostics.Errorf(at(second, 5, 1), diagnostics.CodeRedeclaration, "x уже объявлена").
+		WithNote("имена в одной области должны различаться").
+		WithRelated(at(first, 4, 1), "предыдущее объявление")
+
+	var sb strings.Builder
+	files.Render(&sb, d)
+	want := "b.src:2:5: error[S0010]: x уже объявлена\n" +
+		" 2 | int x = 2;\n" +
+		"   |     ^\n" +
+		"   = note: имена в одной области должны различаться\n" +
+		"a.src:1:5: note: предыдущее объявление\n" +
+		" 1 | int x = 1;\n" +
+		"   |     ^\n"
+	if sb.String() != want {
+		t.Errorf("получено:\n%s\nожидалось:\n%s", sb.String(), want)
+	}
+
+	// Без позиции печатается только сообщение и примечания
+	sb.Reset()
+	files.Render(&sb, diagnostics.Warningf(diagnostics.NoSpan, "", "без позиции").WithNote("деталь"))
+	if want := "warning: без позиции\n  = note: деталь\n"; sb.String() != want {
+		t.Errorf("получено:\n%q\nожидалось:\n%q", sb.String(), want)
+	}
+}
@@ -0,0 +1,236 @@
+package tests
+
+import (
+	"bytes"
+	"compiler_project/backend"
+	"compiler_project/diagnostics"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+var (
+	driverOnce sync.Once
+	driverPath string
+	driverErr  error
+)
+
+// compilerBinary собирает драйвер один раз на весь прогон тестов.
+// Без go в PATH тест пропускается.
+func compilerBinary(t *testing.T) string {
+	t.Helper()
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go не найден в PATH")
+	}
+	driverOnce.Do(func() {
+		dir, err := os.MkdirTemp("", "compiler-driver-")
+		if err != nil {
+			driverErr = err
+			return
+		}
+		driverPath = filepath.Join(dir, "compiler")
+		if out, err := exec.Command(goTool, "build", "-o", driverPath, "compiler_project/cmd").CombinedOutput(); err != nil {
+			driverErr = fmt.Errorf("%v\n%s", err, out)
+		}
+	})
+	if driverErr != nil {
+		t.Fatalf("сборка драйвера: %v", driverErr)
+	}
+	return driverPath
+}
+
+// runCompiler запускает драйвер в каталоге dir с исходным кодом stdin
+// и возвращает stdout, stderr и код завершения
+func runCompiler(t *testing.T, dir, stdin string, args ...string) (string, string, int) {
+	t.Helper()
+	return runCompilerEnv(t, nil, dir, stdin, args...)
+}
+
+// runCompilerEnv — то же с окружением env (nil — окружение теста)
+func runCompilerEnv(t *testing.T, env []string, dir, stdin string, args ...string) (string, string, int) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(compilerBinary(t), args...)
+	cmd.Dir = dir
+	cmd.Env = env
+	cmd.Stdin = strings.NewReader(stdin)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return stdout.String(), stderr.String(), exitErr.ExitCode()
+	}
+	if err != nil {
+		t.Fatalf("запуск драйвера: %v", err)
+	}
+	return stdout.String(), stderr.String(), 0
+}
+
+// TestDriverExitCodes — у каждой стадии свой код завершения
+func TestDriverExitCodes(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name string
+		code string
+		args []string
+		want int
+	}{
+		{"успех", "int x = 1; show x;", nil, 0},
+		{"лексер", `string s = "abc;`, nil, 1},
+		{"парсер", "int x = ;", nil, 2},
+		{"синтаксис в режиме ast", "int x = ;", []string{"--emit=ast"}, 2},
+		{"семантика", `int x = "a";`, nil, 3},
+		{"выполнение", "int z = 0; int y = 1 / z;", []string{"--emit=run"}, 4},
+		{"exit в режиме run", "exit(9);", []string{"--emit=run"}, 9},
+		{"нет файла", "", []string{"missing.src"}, 5},
+		{"неизвестный режим", "", []string{"--emit=bogus"}, 64},
+		{"неизвестная платформа", "", []string{"--target=pdp11"}, 64},
+		{"-O без build", "", []string{"-O2"}, 64},
+		{"-o в режиме run", "show 1;", []string{"--emit=run", "-o", "out.txt"}, 64},
+	}
+	for _, tt := range tests {
+		_, stderr, code := runCompiler(t, dir, tt.code, tt.args...)
+		if code != tt.want {
+			t.Errorf("%s: код завершения %d, ожидался %d\n%s", tt.name, code, tt.want, stderr)
+		}
+	}
+}
+
+// TestDriverOutputFile — -o получает результат только при успешной компиляции
+func TestDriverOutputFile(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out.ll")
+
+	stdout, stderr, code := runCompiler(t, dir, "int x = 1; show x;", "-o", out)
+	if code != 0 {
+		t.Fatalf("код завершения %d\n%s", code, stderr)
+	}
+	if stdout != "" {
+		t.Errorf("с -o в stdout ничего не должно выводиться, получено:\n%s", stdout)
+	}
+	if data, err := os.ReadFile(out); err != nil || !strings.Contains(string(data), "define i32 @main") {
+		t.Errorf("в %s нет модуля LLVM: %v\n%s", out, err, data)
+	}
+
+	if err := os.WriteFile(out, []byte("keep\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, code := runCompiler(t, dir, `string s = "abc;`, "-o", out); code != 1 {
+		t.Errorf("ожидался код 1, получено %d", code)
+	}
+	if data, _ := os.ReadFile(out); string(data) != "keep\n" {
+		t.Errorf("ошибка компиляции изменила %s: %q", out, data)
+	}
+
+	// Источник можно передать файлом; stdin при этом не читается
+	src := filepath.Join(dir, "prog.src")
+	if err := os.WriteFile(src, []byte("show 1;"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	stdout, _, code = runCompiler(t, dir, "это не программа", "--emit=run", src)
+	if code != 0 || stdout != ">> 1\n" {
+		t.Errorf("--emit=run %s: код %d, вывод %q", src, code, stdout)
+	}
+
+	// В режиме run результат — вывод программы, поэтому -o отвергается, а файл не создаётся
+	runOut := filepath.Join(dir, "run.txt")
+	stdout, stderr, code = runCompiler(t, dir, "", "--emit=run", "-o", runOut, src)
+	if code != 64 || stdout != "" || !strings.Contains(stderr, "-o не действует") {
+		t.Errorf("--emit=run -o: код %d, вывод %q\n%s", code, stdout, stderr)
+	}
+	if _, err := os.Stat(runOut); err == nil {
+		t.Errorf("--emit=run -o создал %s", runOut)
+	}
+}
+
+// writeSource создаёт файл с исходным кодом в каталоге dir
+func writeSource(t *testing.T, dir, name, code string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(code), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestDriverBuild — режим build собирает исполняемый и объектный файлы
+// найденными инструментами. Без них тест пропускается.
+func TestDriverBuild(t *testing.T) {
+	if _, ok := backend.FindToolchain(); !ok {
+		t.Skip("не найден clang или llc с cc")
+	}
+	dir := t.TempDir()
+	writeSource(t, dir, "prog.src", "show 6 * 7; exit(2);")
+
+	if _, stderr, code := runCompiler(t, dir, "", "--emit=build", "-O2", "prog.src"); code != 0 {
+		t.Fatalf("код завершения %d\n%s", code, stderr)
+	}
+	out, err := exec.Command(filepath.Join(dir, "prog")).Output()
+	if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.ExitCode() != 2 || string(out) != ">> 42\n" {
+		t.Errorf("./prog: вывод %q, ошибка %v", out, err)
+	}
+
+	if _, stderr, code := runCompiler(t, dir, "", "--emit=build", "-c", "prog.src"); code != 0 {
+		t.Fatalf("-c: код завершения %d\n%s", code, stderr)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "prog.o")); err != nil {
+		t.Errorf("-c: нет prog.o: %v", err)
+	}
+}
+
+// TestDriverBuildFallback — без инструментов IR записывается в .ll
+// с предупреждением, а драйвер завершается успешно
+func TestDriverBuildFallback(t *testing.T) {
+	dir := t.TempDir()
+	writeSource(t, dir, "prog.src", "show 1;")
+
+	env := []string{"PATH=" + t.TempDir()}
+	_, stderr, code := runCompilerEnv(t, env, dir, "", "--emit=build", "prog.src")
+	if code != 0 {
+		t.Fatalf("код завершения %d\n%s", code, stderr)
+	}
+	if !strings.Contains(stderr, "warning["+diagnostics.CodeToolchainMissing+"]") || !strings.Contains(stderr, "prog.ll") {
+		t.Errorf("нет предупреждения о недостающих инструментах:\n%s", stderr)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "prog.ll"))
+	if err != nil || !strings.Contains(string(data), "define i32 @main") {
+		t.Errorf("prog.ll не записан: %v\n%s", err, data)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "prog")); err == nil {
+		t.Errorf("без инструментов появился исполняемый файл")
+	}
+}
+
+// TestDriverProtectsSources — драйвер отказывается писать результат поверх входного файла
+func TestDriverProtectsSources(t *testing.T) {
+	dir := t.TempDir()
+	const code = "show 1;"
+	for _, tt := range []struct {
+		source string
+		args   []string
+	}{
+		// Имя по умолчанию — имя исходника без расширения, то есть он сам
+		{"prog", []string{"--emit=build", "prog"}},
+		{"prog", []string{"--emit=build", "./prog"}},
+		{"prog.o", []string{"--emit=build", "-c", "prog.o"}},
+		// Запасной .ll совпадает с исходником
+		{"prog.ll", []string{"--emit=build", "prog.ll"}},
+		{"prog.src", []string{"-o", "prog.src", "prog.src"}},
+		{"prog.src", []string{"--emit=tac", "-o", filepath.Join(dir, "prog.src"), "prog.src"}},
+	} {
+		path := writeSource(t, dir, tt.source, code)
+		_, stderr, exit := runCompiler(t, dir, "", tt.args...)
+		if exit != 64 || !strings.Contains(stderr, "совпадает с входным файлом") {
+			t.Errorf("%v: код завершения %d\n%s", tt.args, exit, stderr)
+		}
+		if data, _ := os.ReadFile(path); string(data) != code {
+			t.Errorf("%v: исходник %s изменён: %q", tt.args, tt.source, data)
+		}
+		os.Remove(path)
+	}
+}
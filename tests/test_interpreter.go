@@ -0,0 +1,166 @@
+package tests
+
+import (
+	"compiler_project/diagnostics"
+	"compiler_project/interp"
+	"compiler_project/lexer"
+	"compiler_project/parser"
+	"strings"
+	"testing"
+)
+
+// runProgram выполняет программу интерпретатором без проверки типов
+// и возвращает его, напечатанное show и ошибку выполнения
+func runProgram(t *testing.T, code string, maxDepth int) (*interp.Interpreter, string, error) {
+	t.Helper()
+	l := lexer.NewLexer(code)
+	p := parser.NewParser(*l.LexerAnalysis())
+	root := p.ParseCode()
+	if len(l.Diagnostics) > 0 || len(p.Diagnostics) > 0 {
+		t.Fatalf("%q: неожиданные синтаксические ошибки: %v %v", code, l.Diagnostics, p.Diagnostics)
+	}
+	var out strings.Builder
+	in := interp.New(&out)
+	in.MaxCallDepth = maxDepth
+	err := in.Run(root)
+	return in, out.String(), err
+}
+
+// expectRuntimeError проверяет код ошибки выполнения
+func expectRuntimeError(t *testing.T, code string, want string) diagnostics.Diagnostic {
+	t.Helper()
+	_, _, err := runProgram(t, code, interp.DefaultMaxCallDepth)
+	d, ok := err.(diagnostics.Diagnostic)
+	if !ok || d.Code != want {
+		t.Errorf("%q: ожидалась ошибка %s, получено %v", code, want, err)
+	}
+	return d
+}
+
+// TestCallFrames — параметры вызова не затирают переменные вызывающего,
+// а рекурсивные вызовы получают собственные кадры
+func TestCallFrames(t *testing.T) {
+	in, _, err := runProgram(t, `
+		int n = 100;
+		func fact(int n) int { if n <= 1 { return 1; }; return n * fact(n - 1); };
+		func fib(int k) int { if k < 2 { return k; }; return fib(k - 1) + fib(k - 2); };
+		int f = fact(5);
+		int g = fib(10);
+	`, interp.DefaultMaxCallDepth)
+	if err != nil {
+		t.Fatalf("неожиданная ошибка выполнения: %v", err)
+	}
+	for name, want := range map[string]int32{"n": 100, "f": 120, "g": 55} {
+		if got, _ := in.Global(name); got != interp.IntValue(want) {
+			t.Errorf("%s = %#v, ожидалось %d", name, got, want)
+		}
+	}
+	if in.StackDepth() != 0 {
+		t.Errorf("после выполнения остались кадры вызовов: %d", in.StackDepth())
+	}
+}
+
+// TestRuntimeErrors — ошибки выполнения возвращаются диагностикой со стеком вызовов
+func TestRuntimeErrors(t *testing.T) {
+	_, _, err := runProgram(t, "func inf(int n) int { return inf(n + 1); }; int r = inf(0);", 20)
+	d, ok := err.(diagnostics.Diagnostic)
+	if !ok || d.Code != diagnostics.CodeRecursionLimit {
+		t.Fatalf("ожидалась ошибка %s, получено %v", diagnostics.CodeRecursionLimit, err)
+	}
+	// Внутренний вызов, отметка о повторах и вызов с верхнего уровня
+	if len(d.Related) != 3 {
+		t.Errorf("стек вызовов: %+v", d.Related)
+	}
+
+	// Checker не пропустит такие программы, поэтому проверяем интерпретатор напрямую
+	expectRuntimeError(t, "func f(int a) { }; f();", diagnostics.CodeArityMismatch)
+	expectRuntimeError(t, "int x = 1 + \"a\";", diagnostics.CodeRuntimeType)
+	expectRuntimeError(t, "if 1 { };", diagnostics.CodeRuntimeType)
+	expectRuntimeError(t, "func f(int a) { }; f(1.5);", diagnostics.CodeRuntimeType)
+	expectRuntimeError(t, "int x = 1; x = 2.5;", diagnostics.CodeRuntimeType)
+	expectRuntimeError(t, "show y;", diagnostics.CodeRuntimeError)
+
+	d = expectRuntimeError(t, "func div(int a, int b) int { return a / b; }; int z = div(1, 0);", diagnostics.CodeZeroDivision)
+	if len(d.Related) != 1 {
+		t.Errorf("стек вызовов при делении на ноль: %+v", d.Related)
+	}
+	expectRuntimeError(t, "int zero = 0; int m = 5 % zero;", diagnostics.CodeZeroDivision)
+}
+
+// TestInterpreterValues — int ведёт себя как i32, вещественные — как IEEE 754,
+// show печатает в переданный writer
+func TestInterpreterValues(t *testing.T) {
+	_, out, err := runProgram(t, `
+		int big = 2147483647;
+		big = big + 1;
+		show big;
+		int p = 2 ** 31;
+		show p;
+		double zero = 0.0;
+		double inf = 1.0 / zero;
+		show inf;
+		boolean nan = zero / zero != zero / zero;
+		show nan;
+		boolean lt = 1.5 < 2.5;
+		show lt;
+		string s = "a" + "b";
+		show s;
+		int sh = -7 >> 1;
+		show sh;
+	`, interp.DefaultMaxCallDepth)
+	if err != nil {
+		t.Fatalf("неожиданная ошибка выполнения: %v", err)
+	}
+	want := ">> -2147483648\n>> -2147483648\n>> inf\n>> true\n>> true\n>> ab\n>> -4\n"
+	if out != want {
+		t.Errorf("получено:\n%s\nожидалось:\n%s", out, want)
+	}
+}
+
+// shortCircuitProgram — правый операнд and/or выполняется, только если нужен:
+// иначе деление на ноль, бесконечная рекурсия или лишний show
+const shortCircuitProgram = `
+	int x = 0;
+	if x != 0 && 10 / x > 1 { show "делится"; };
+	show x == 0 || 10 / x > 1;
+	func f(int n) boolean { return n <= 0 || f(n - 1); };
+	show f(3);
+	func loud(boolean v) boolean { show v; return v; };
+	show loud(false) and loud(true);
+	show loud(true) or loud(false);
+	show loud(true) && loud(false);
+	show loud(false) || loud(true);
+`
+
+// TestShortCircuit — интерпретатор не вычисляет лишний правый операнд
+func TestShortCircuit(t *testing.T) {
+	_, out, err := runProgram(t, shortCircuitProgram, interp.DefaultMaxCallDepth)
+	if err != nil {
+		t.Fatalf("неожиданная ошибка выполнения: %v", err)
+	}
+	want := ">> true\n>> true\n" +
+		">> false\n>> false\n" +
+		">> true\n>> true\n" +
+		">> true\n>> false\n>> false\n" +
+		">> false\n>> true\n>> true\n"
+	if out != want {
+		t.Errorf("получено:\n%s\nожидалось:\n%s", out, want)
+	}
+}
+
+// TestNegativeExponent — при отрицательном показателе целая степень усекается
+// к нулю; целой она остаётся только у 1 и -1
+func TestNegativeExponent(t *testing.T) {
+	_, out, err := runProgram(t, `
+		int e = -3;
+		show 2 ** e; show 0 ** e; show 1 ** e;
+		int m = -1;
+		show m ** e; show m ** (e - 1);
+	`, interp.DefaultMaxCallDepth)
+	if err != nil {
+		t.Fatalf("неожиданная ошибка выполнения: %v", err)
+	}
+	if want := ">> 0\n>> 0\n>> 1\n>> -1\n>> 1\n"; out != want {
+		t.Errorf("получено:\n%s\nожидалось:\n%s", out, want)
+	}
+}
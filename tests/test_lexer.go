@@ -1 +1,280 @@
 package tests
+
+import (
+	"compiler_project/diagnostics"
+	"compiler_project/lexer"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// GenerateProgram строит синтетическую программу примерно из lines строк,
+// в которой встречаются все виды токенов
+func GenerateProgram(lines int) string {
+	var sb strings.Builder
+	for i := 0; i*10 < lines; i++ {
+		fmt.Fprintf(&sb, "int x%d = %d + %d * (%d - 1) / 2;\n", i, i, i+1, i+2)
+		fmt.Fprintf(&sb, "double d%d = %d.5;\n", i, i)
+		fmt.Fprintf(&sb, "string s%d = \"line %d\";\n", i, i)
+		fmt.Fprintf(&sb, "boolean b%d = true and false or x%d less %d;\n", i, i, i*3)
+		fmt.Fprintf(&sb, "while x%d more 0 {\n\tshow x%d;\n\tint x%d = x%d - 1;\n};\n", i, i, i, i)
+		fmt.Fprintf(&sb, "if x%d equal 1 { show s%d; } else { show d%d; };\n", i, i, i)
+		fmt.Fprintf(&sb, "func f%d(a, b) { show a; };\n", i)
+	}
+	return sb.String()
+}
+
+// TestLexerMatchesRegexpLexer проверяет, что однопроходный лексер выдаёт
+// тот же поток токенов (типы, текст и позиции), что и версия на регулярных выражениях.
+// В сгенерированной программе нет идентификаторов, начинающихся с ключевого слова,
+// — на них старая версия ошибалась (см. TestKeywordBoundaries).
+func TestLexerMatchesRegexpLexer(t *testing.T) {
+	code := GenerateProgram(500)
+
+	got := *lexer.NewLexer(code).LexerAnalysis()
+	want := *newRegexpLexer(code).LexerAnalysis()
+
+	if len(got) != len(want) {
+		t.Fatalf("количество токенов: получено %d, ожидалось %d", len(got), len(want))
+	}
+	// Value (декодированная строка) старая версия не заполняет, сравниваем остальное
+	for i := range want {
+		if got[i].TypeToken != want[i].TypeToken || got[i].Text != want[i].Text || got[i].Pos != want[i].Pos {
+			t.Fatalf("токен %d: получено %+v, ожидалось %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func benchmarkLexer(b *testing.B, lines int, analyze func(code string)) {
+	code := GenerateProgram(lines)
+	b.SetBytes(int64(len(code)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		analyze(code)
+	}
+}
+
+func BenchmarkLexer10k(b *testing.B) {
+	benchmarkLexer(b, 10000, func(code string) {
+		lexer.NewLexer(code).LexerAnalysis()
+	})
+}
+
+func BenchmarkRegexpLexer10k(b *testing.B) {
+	benchmarkLexer(b, 10000, func(code string) {
+		newRegexpLexer(code).LexerAnalysis()
+	})
+}
+
+func BenchmarkLexer1k(b *testing.B) {
+	benchmarkLexer(b, 1000, func(code string) {
+		lexer.NewLexer(code).LexerAnalysis()
+	})
+}
+
+func BenchmarkRegexpLexer1k(b *testing.B) {
+	benchmarkLexer(b, 1000, func(code string) {
+		newRegexpLexer(code).LexerAnalysis()
+	})
+}
+
+// keywordWords возвращает все ключевые слова из TokenTypeList (те, чей шаблон — просто слово)
+func keywordWords() map[string]lexer.TokenType {
+	result := map[string]lexer.TokenType{}
+	for _, tt := range *lexer.TokenTypeList {
+		if tt.Regex == "" || tt.Regex[0] == '-' || strings.Trim(tt.Regex, "abcdefghijklmnopqrstuvwxyz-") != "" {
+			continue
+		}
+		result[tt.Regex] = tt
+	}
+	return result
+}
+
+func lexNames(t *testing.T, code string) []string {
+	t.Helper()
+	l := lexer.NewLexer(code)
+	tokens := *l.LexerAnalysis()
+	if len(l.Diagnostics) > 0 {
+		t.Fatalf("%q: неожиданные ошибки лексера: %v", code, l.Diagnostics)
+	}
+	names := make([]string, len(tokens))
+	for i, tok := range tokens {
+		names[i] = tok.TypeToken.Name + ":" + tok.Text
+	}
+	return names
+}
+
+func expectTokens(t *testing.T, code string, want ...string) {
+	t.Helper()
+	got := lexNames(t, code)
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("%q: получено %v, ожидалось %v", code, got, want)
+	}
+}
+
+// TestKeywordBoundaries — регрессия: идентификатор, который начинается с ключевого
+// слова или заканчивается им, целиком остаётся VARIABLE
+func TestKeywordBoundaries(t *testing.T) {
+	for word, tt := range keywordWords() {
+		if kw, ok := lexer.Keywords[word]; ok {
+			if kw != tt {
+				t.Errorf("%q: в Keywords тип %v, в TokenTypeList %v", word, kw, tt)
+			}
+			expectTokens(t, word, tt.Name+":"+word)
+			expectTokens(t, word+"(", tt.Name+":"+word, "LPAREN:(")
+			expectTokens(t, word+";", tt.Name+":"+word, "SEMICOLON:;")
+		} else {
+			// Слово есть в TokenTypeList, но лексер его не выделяет (например, var)
+			expectTokens(t, word, "VARIABLE:"+word)
+		}
+
+		if strings.Contains(word, "-") {
+			// xnon-equal и non-equalx — это идентификаторы и минус, но не NONEQUAL
+			for _, code := range []string{"x" + word, word + "x", word + "_1"} {
+				for _, name := range lexNames(t, code) {
+					if strings.HasPrefix(name, tt.Name+":") {
+						t.Errorf("%q: ключевое слово %s выделено внутри идентификатора", code, tt.Name)
+					}
+				}
+			}
+			continue
+		}
+		expectTokens(t, "x"+word, "VARIABLE:x"+word)
+		expectTokens(t, "_"+word, "VARIABLE:_"+word)
+		for _, suffix := range []string{"x", "_", "1", "Count", word} {
+			expectTokens(t, word+suffix, "VARIABLE:"+word+suffix)
+		}
+	}
+
+	expectTokens(t, "int interval = 1;", "int:int", "VARIABLE:interval", "ASSIGN:=", "INTEGER:1", "SEMICOLON:;")
+	expectTokens(t, "order or iffy", "VARIABLE:order", "OR:or", "VARIABLE:iffy")
+	expectTokens(t, "show showCount", "show:show", "VARIABLE:showCount")
+	expectTokens(t, "a non-equal b", "VARIABLE:a", "NONEQUAL:non-equal", "VARIABLE:b")
+	expectTokens(t, "integer more lesson", "VARIABLE:integer", "MORE:more", "VARIABLE:lesson")
+}
+
+// TestComments проверяет, что комментарии пропускаются и при KeepTrivia
+// сохраняются на следующем токене
+func TestComments(t *testing.T) {
+	code := "// заголовок\nint a = 1; /* внешний /* вложенный */ ещё */ show a; // хвост"
+	expectTokens(t, code, "int:int", "VARIABLE:a", "ASSIGN:=", "INTEGER:1", "SEMICOLON:;",
+		"show:show", "VARIABLE:a", "SEMICOLON:;")
+	expectTokens(t, "a / b", "VARIABLE:a", "DIVIDE:/", "VARIABLE:b")
+
+	l := lexer.NewLexer(code)
+	l.KeepTrivia = true
+	tokens := *l.LexerAnalysis()
+	if len(tokens[0].LeadingTrivia) != 1 || tokens[0].LeadingTrivia[0].Text != "// заголовок" {
+		t.Errorf("комментарий перед int: %+v", tokens[0].LeadingTrivia)
+	}
+	if len(tokens[5].LeadingTrivia) != 1 || tokens[5].LeadingTrivia[0].Kind != lexer.BlockComment {
+		t.Errorf("комментарий перед show: %+v", tokens[5].LeadingTrivia)
+	}
+	if len(l.TrailingTrivia) != 1 || l.TrailingTrivia[0].Text != "// хвост" {
+		t.Errorf("комментарий в конце: %+v", l.TrailingTrivia)
+	}
+
+	unterminated := lexer.NewLexer("int a = 1; /* /* */")
+	unterminated.LexerAnalysis()
+	if len(unterminated.Diagnostics) != 1 {
+		t.Errorf("незакрытый комментарий: %v", unterminated.Diagnostics)
+	}
+}
+
+// TestStringEscapes проверяет декодирование escape-последовательностей в Token.Value
+func TestStringEscapes(t *testing.T) {
+	code := `"a\tb\n\"c\" \\ \u{41}\u{1F600}"`
+	l := lexer.NewLexer(code)
+	tokens := *l.LexerAnalysis()
+	if len(l.Diagnostics) > 0 || len(tokens) != 1 {
+		t.Fatalf("получено %v, ошибки %v", tokens, l.Diagnostics)
+	}
+	if want := "a\tb\n\"c\" \\ A😀"; tokens[0].Value != want {
+		t.Errorf("значение: получено %q, ожидалось %q", tokens[0].Value, want)
+	}
+	if tokens[0].Text != code {
+		t.Errorf("текст токена должен совпадать с исходником: %q", tokens[0].Text)
+	}
+
+	// \u{0} оборвал бы C-строку в скомпилированной программе
+	for _, bad := range []string{`"\q"`, `"\u{110000}"`, `"\u{}"`, `"\u41"`, `"a\u{0}b"`, `"\u{000}"`} {
+		l := lexer.NewLexer(bad)
+		l.LexerAnalysis()
+		if len(l.Diagnostics) != 1 || l.Diagnostics[0].Code != diagnostics.CodeInvalidEscape {
+			t.Errorf("%s: ожидалась одна ошибка %s, получено %v", bad, diagnostics.CodeInvalidEscape, l.Diagnostics)
+		}
+	}
+}
+
+// TestNumericLiterals проверяет формы числовых констант и их значения
+func TestNumericLiterals(t *testing.T) {
+	ints := map[string]int64{"0xFF": 255, "0b1010": 10, "0o17": 15, "1_000_000": 1000000, "0123": 123, "2147483647": 2147483647}
+	for text, want := range ints {
+		expectTokens(t, text, "INTEGER:"+text)
+		if got, err := lexer.ParseIntLiteral(text); err != nil || got != want {
+			t.Errorf("%s: получено %d (%v), ожидалось %d", text, got, err, want)
+		}
+	}
+
+	floats := map[string]float64{"1e9": 1e9, ".5": 0.5, "2.5e-3": 2.5e-3, "1_000.25": 1000.25, "3E+2": 300}
+	for text, want := range floats {
+		expectTokens(t, text, "DOUBLE:"+text)
+		if got, err := lexer.ParseFloatLiteral(text); err != nil || got != want {
+			t.Errorf("%s: получено %g (%v), ожидалось %g", text, got, err, want)
+		}
+	}
+
+	for _, bad := range []string{"1__0", "1_", "0x", "0b102"} {
+		l := lexer.NewLexer(bad)
+		l.LexerAnalysis()
+		if len(l.Diagnostics) != 1 {
+			t.Errorf("%s: ожидалась одна ошибка, получено %v", bad, l.Diagnostics)
+		}
+	}
+
+	// Диапазон проверяет парсер: лексеру 2147483648 не ошибка,
+	// а ParseIntLiteral принимает его только со знаком
+	for _, big := range []string{"2147483648", "0xFFFFFFFF"} {
+		expectTokens(t, big, "INTEGER:"+big)
+		if _, err := lexer.ParseIntLiteral(big); !errors.Is(err, strconv.ErrRange) {
+			t.Errorf("%s: ожидалась ошибка диапазона, получено %v", big, err)
+		}
+	}
+	for _, text := range []string{"-2147483648", "-0x8000_0000"} {
+		if got, err := lexer.ParseIntLiteral(text); err != nil || got != math.MinInt32 {
+			t.Errorf("%s: получено %d (%v), ожидалось %d", text, got, err, math.MinInt32)
+		}
+	}
+	if _, err := lexer.ParseIntLiteral("-2147483649"); !errors.Is(err, strconv.ErrRange) {
+		t.Errorf("-2147483649: ожидалась ошибка диапазона, получено %v", err)
+	}
+}
+
+// TestComparisonOperators — двухсимвольные операторы читаются целиком,
+// словесные формы с дефисом не путаются с вычитанием
+func TestComparisonOperators(t *testing.T) {
+	expectTokens(t, "a<=b", "VARIABLE:a", "LE:<=", "VARIABLE:b")
+	expectTokens(t, "a>=b", "VARIABLE:a", "GE:>=", "VARIABLE:b")
+	expectTokens(t, "a==b!=c", "VARIABLE:a", "EQ:==", "VARIABLE:b", "NEQ:!=", "VARIABLE:c")
+	expectTokens(t, "a<b>c", "VARIABLE:a", "LT:<", "VARIABLE:b", "GT:>", "VARIABLE:c")
+	expectTokens(t, "a&&!b||c", "VARIABLE:a", "LAND:&&", "LNOT:!", "VARIABLE:b", "LOR:||", "VARIABLE:c")
+	expectTokens(t, "a = b", "VARIABLE:a", "ASSIGN:=", "VARIABLE:b")
+	expectTokens(t, "a less-or-equal b", "VARIABLE:a", "LESSOREQUAL:less-or-equal", "VARIABLE:b")
+	expectTokens(t, "a more-or-equal b", "VARIABLE:a", "MOREOREQUAL:more-or-equal", "VARIABLE:b")
+	expectTokens(t, "not a", "NOT:not", "VARIABLE:a")
+	expectTokens(t, "less-x", "LESS:less", "MINUS:-", "VARIABLE:x")
+}
+
+func TestCompoundOperators(t *testing.T) {
+	expectTokens(t, "a+=1", "VARIABLE:a", "PLUSASSIGN:+=", "INTEGER:1")
+	expectTokens(t, "a-=b*=c/=d%=e", "VARIABLE:a", "MINUSASSIGN:-=", "VARIABLE:b", "MULTIPLYASSIGN:*=",
+		"VARIABLE:c", "DIVIDEASSIGN:/=", "VARIABLE:d", "MODULOASSIGN:%=", "VARIABLE:e")
+	expectTokens(t, "i++;j--;", "VARIABLE:i", "INCREMENT:++", "SEMICOLON:;", "VARIABLE:j", "DECREMENT:--", "SEMICOLON:;")
+	expectTokens(t, "a%b", "VARIABLE:a", "MODULO:%", "VARIABLE:b")
+	expectTokens(t, "a+ +b", "VARIABLE:a", "PLUS:+", "PLUS:+", "VARIABLE:b")
+	// "/=" не путается с комментарием
+	expectTokens(t, "a/=2//x", "VARIABLE:a", "DIVIDEASSIGN:/=", "INTEGER:2")
+}
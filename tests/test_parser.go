@@ -1 +1,291 @@
 package tests
+
+import (
+	"compiler_project/diagnostics"
+	"compiler_project/lexer"
+	"compiler_project/parser"
+	"compiler_project/parser/ast"
+	"strings"
+	"testing"
+)
+
+// parseDump разбирает программу и возвращает ast.Dump без лидирующего "Statements"
+func parseDump(t *testing.T, code string) string {
+	t.Helper()
+	l := lexer.NewLexer(code)
+	tokens := *l.LexerAnalysis()
+	if len(l.Diagnostics) > 0 {
+		t.Fatalf("%q: неожиданные ошибки лексера: %v", code, l.Diagnostics)
+	}
+	p := parser.NewParser(tokens)
+	root := p.ParseCode()
+	if len(p.Diagnostics) > 0 {
+		t.Fatalf("%q: неожиданные ошибки парсера: %v", code, p.Diagnostics)
+	}
+	return strings.TrimPrefix(ast.Dump(root), "Statements\n")
+}
+
+func expectTree(t *testing.T, code string, want ...string) {
+	t.Helper()
+	got := parseDump(t, code)
+	if got != strings.Join(want, "\n")+"\n" {
+		t.Errorf("%q: получено дерево\n%s\nожидалось\n%s", code, got, strings.Join(want, "\n"))
+	}
+}
+
+// TestUnaryPrecedence — унарные операторы связывают сильнее бинарных
+// и могут идти подряд
+func TestUnaryPrecedence(t *testing.T) {
+	expectTree(t, "int x = -a * b;",
+		"  TypedAssign int x",
+		"    BinOperation *",
+		"      UnarOperation -",
+		"        Variable a",
+		"      Variable b",
+	)
+	expectTree(t, "int x = a - -b;",
+		"  TypedAssign int x",
+		"    BinOperation -",
+		"      Variable a",
+		"      UnarOperation -",
+		"        Variable b",
+	)
+	expectTree(t, "boolean x = !a && not not b;",
+		"  TypedAssign boolean x",
+		"    BinOperation &&",
+		"      UnarOperation !",
+		"        Variable a",
+		"      UnarOperation not",
+		"        UnarOperation not",
+		"          Variable b",
+	)
+}
+
+// TestIntLiteralRange — 2147483648 допустим только как операнд унарного минуса
+// и тогда становится литералом -2147483648; остальные выходы за int32 — ошибка
+func TestIntLiteralRange(t *testing.T) {
+	expectTree(t, "int x = -2147483648;",
+		"  TypedAssign int x",
+		"    Number -2147483648",
+	)
+	expectTree(t, "int x = -5 - -0x80000000;",
+		"  TypedAssign int x",
+		"    BinOperation -",
+		"      UnarOperation -",
+		"        Number 5",
+		"      Number -0x80000000",
+	)
+
+	for _, code := range []string{
+		"int x = 2147483648;",
+		"int x = -(2147483648);",
+		"int x = -2147483648 ** 2;",
+		"int x = -2147483649;",
+		"int x = 0xFFFFFFFF;",
+	} {
+		l := lexer.NewLexer(code)
+		p := parser.NewParser(*l.LexerAnalysis())
+		p.ParseCode()
+		if len(l.Diagnostics) != 0 || len(p.Diagnostics) != 1 || p.Diagnostics[0].Code != diagnostics.CodeIntOverflow {
+			t.Errorf("%q: ожидалась одна ошибка %s парсера, получено %v %v",
+				code, diagnostics.CodeIntOverflow, l.Diagnostics, p.Diagnostics)
+		}
+	}
+}
+
+// TestAssignment — "a = ..." без типа разбирается в AssignNode, а не в выражение
+func TestAssignment(t *testing.T) {
+	expectTree(t, "a = a + 1;",
+		"  Assign a",
+		"    BinOperation +",
+		"      Variable a",
+		"      Number 1",
+	)
+	expectTree(t, "int a = 1; a = -a;",
+		"  TypedAssign int a",
+		"    Number 1",
+		"  Assign a",
+		"    UnarOperation -",
+		"      Variable a",
+	)
+}
+
+// TestCompoundAssignment — составные присваивания раскрываются парсером
+// в обычное присваивание над бинарной операцией
+func TestCompoundAssignment(t *testing.T) {
+	for _, tc := range []struct{ code, op string }{
+		{"a += b;", "+="}, {"a -= b;", "-="}, {"a *= b;", "*="}, {"a /= b;", "/="}, {"a %= b;", "%="},
+	} {
+		expectTree(t, tc.code,
+			"  Assign a",
+			"    BinOperation "+tc.op,
+			"      Variable a",
+			"      Variable b",
+		)
+	}
+	expectTree(t, "i++; i--;",
+		"  Assign i",
+		"    BinOperation ++",
+		"      Variable i",
+		"      Number 1",
+		"  Assign i",
+		"    BinOperation --",
+		"      Variable i",
+		"      Number 1",
+	)
+	// Правая часть разбирается целиком: a *= b + 1 — это a = a * (b + 1)
+	expectTree(t, "a *= b + 1;",
+		"  Assign a",
+		"    BinOperation *=",
+		"      Variable a",
+		"      BinOperation +",
+		"        Variable b",
+		"        Number 1",
+	)
+}
+
+// TestOperatorPrecedence — таблица приоритетов из комментария к parseFormula
+func TestOperatorPrecedence(t *testing.T) {
+	expectTree(t, "int x = a | b ^ c & d << e + f % g;",
+		"  TypedAssign int x",
+		"    BinOperation |",
+		"      Variable a",
+		"      BinOperation ^",
+		"        Variable b",
+		"        BinOperation &",
+		"          Variable c",
+		"          BinOperation <<",
+		"            Variable d",
+		"            BinOperation +",
+		"              Variable e",
+		"              BinOperation %",
+		"                Variable f",
+		"                Variable g",
+	)
+	// Побитовые операции сильнее сравнений: a & 1 == 0 — это (a & 1) == 0
+	expectTree(t, "boolean x = a & 1 == 0;",
+		"  TypedAssign boolean x",
+		"    BinOperation ==",
+		"      BinOperation &",
+		"        Variable a",
+		"        Number 1",
+		"      Number 0",
+	)
+	// ** правоассоциативна и сильнее унарного минуса слева
+	expectTree(t, "int x = -a ** b ** -c;",
+		"  TypedAssign int x",
+		"    UnarOperation -",
+		"      BinOperation **",
+		"        Variable a",
+		"        BinOperation **",
+		"          Variable b",
+		"          UnarOperation -",
+		"            Variable c",
+	)
+}
+
+// TestLogicalPrecedence — || слабее &&, && слабее равенств, равенства слабее
+// сравнений; словесные формы разбираются так же, как символьные
+func TestLogicalPrecedence(t *testing.T) {
+	expectTree(t, "boolean x = !a || b && c <= d;",
+		"  TypedAssign boolean x",
+		"    BinOperation ||",
+		"      UnarOperation !",
+		"        Variable a",
+		"      BinOperation &&",
+		"        Variable b",
+		"        BinOperation <=",
+		"          Variable c",
+		"          Variable d",
+	)
+	// Равенства левоассоциативны и слабее >= и <
+	expectTree(t, "boolean x = a >= b == c != d < e;",
+		"  TypedAssign boolean x",
+		"    BinOperation !=",
+		"      BinOperation ==",
+		"        BinOperation >=",
+		"          Variable a",
+		"          Variable b",
+		"        Variable c",
+		"      BinOperation <",
+		"        Variable d",
+		"        Variable e",
+	)
+	expectTree(t, "boolean x = not a or b and c less-or-equal d;",
+		"  TypedAssign boolean x",
+		"    BinOperation or",
+		"      UnarOperation not",
+		"        Variable a",
+		"      BinOperation and",
+		"        Variable b",
+		"        BinOperation less-or-equal",
+		"          Variable c",
+		"          Variable d",
+	)
+}
+
+func TestFunctionSignature(t *testing.T) {
+	expectTree(t, "func add(int a, double b) int { return a; };",
+		"  Function add(int a, double b) int",
+		"    Statements",
+		"      Return",
+		"        Variable a",
+	)
+	expectTree(t, "func log() { return; };",
+		"  Function log()",
+		"    Statements",
+		"      Return",
+	)
+}
+
+// TestSyntaxRecovery — после ошибки парсер пропускает инструкцию до ';' или '}',
+// сообщает обо всех ошибках за один проход и возвращает дерево из уцелевших инструкций
+func TestSyntaxRecovery(t *testing.T) {
+	code := "int a = 1;\n" +
+		"if a > 0 { show a };\n" +
+		"while ) { show a; };\n" +
+		"func f(int x, ) { show x; };\n" +
+		"int b = 2;\n"
+	l := lexer.NewLexer(code)
+	p := parser.NewParser(*l.LexerAnalysis())
+	root := p.ParseCode()
+
+	want := []struct {
+		code      string
+		line, col int
+	}{
+		{diagnostics.CodeUnexpectedToken, 2, 19},   // нет ';' перед '}' в теле if
+		{diagnostics.CodeExpectedExpression, 3, 7}, // нет условия while
+		{diagnostics.CodeUnexpectedToken, 4, 15},   // пустой параметр
+	}
+	f := diagnostics.NewFile("a.src", code)
+	if len(p.Diagnostics) != len(want) {
+		t.Fatalf("ожидалось %d ошибок, получено %d: %v", len(want), len(p.Diagnostics), p.Diagnostics)
+	}
+	for i, w := range want {
+		d := p.Diagnostics[i]
+		line, col := f.Position(d.Span.Start)
+		if d.Code != w.code || line != w.line || col != w.col {
+			t.Errorf("ошибка %d: %s в %d:%d, ожидалась %s в %d:%d", i, d.Code, line, col, w.code, w.line, w.col)
+		}
+	}
+
+	// if сохраняется без недостающей ';', while и func отбрасываются целиком
+	got := strings.TrimPrefix(ast.Dump(root), "Statements\n")
+	wantTree := strings.Join([]string{
+		"  TypedAssign int a",
+		"    Number 1",
+		"  If",
+		"    BinOperation >",
+		"      Variable a",
+		"      Number 0",
+		"    Statements",
+		"      Show",
+		"        Variable a",
+		"  TypedAssign int b",
+		"    Number 2",
+	}, "\n") + "\n"
+	if got != wantTree {
+		t.Errorf("получено дерево\n%s\nожидалось\n%s", got, wantTree)
+	}
+}
@@ -0,0 +1,225 @@
+package tests
+
+import (
+	"compiler_project/diagnostics"
+	"compiler_project/lexer"
+	"compiler_project/parser"
+	"compiler_project/semantics"
+	"strings"
+	"testing"
+)
+
+// checkProgram прогоняет лексер, парсер и проверку типов; возвращает код
+// семантической ошибки или "" если программа корректна
+func checkProgram(t *testing.T, code string) string {
+	t.Helper()
+	l := lexer.NewLexer(code)
+	tokens := *l.LexerAnalysis()
+	p := parser.NewParser(tokens)
+	root := p.ParseCode()
+	if len(l.Diagnostics) > 0 || len(p.Diagnostics) > 0 {
+		t.Fatalf("%q: неожиданные синтаксические ошибки: %v %v", code, l.Diagnostics, p.Diagnostics)
+	}
+	_, err := semantics.NewTypeChecker().Check(root)
+	if err == nil {
+		return ""
+	}
+	d, ok := err.(diagnostics.Diagnostic)
+	if !ok {
+		t.Fatalf("%q: ошибка без кода: %v", code, err)
+	}
+	return d.Code
+}
+
+func expectCheck(t *testing.T, code string, want string) {
+	t.Helper()
+	if got := checkProgram(t, code); got != want {
+		t.Errorf("%q: получен код %q, ожидался %q", code, got, want)
+	}
+}
+
+func TestAssignmentChecks(t *testing.T) {
+	expectCheck(t, "int a = 1; a = a + 1;", "")
+	expectCheck(t, "b = 1;", diagnostics.CodeUndefinedVariable)
+	expectCheck(t, "int a = 1; a = 1.5;", diagnostics.CodeTypeMismatch)
+	expectCheck(t, "int a = 1; int a = 2;", diagnostics.CodeRedeclaration)
+	expectCheck(t, "int a = 1; double a = 2.0;", diagnostics.CodeRedeclaration)
+	// Справа от объявления переменная ещё не видна
+	expectCheck(t, "int a = a + 1;", diagnostics.CodeUndefinedVariable)
+}
+
+func TestCompoundAssignmentChecks(t *testing.T) {
+	expectCheck(t, "int i = 0; i++; i--; i += 2; i -= 1; i *= 3; i /= 2; i %= 2;", "")
+	expectCheck(t, "double d = 1.0; d += 0.5; d /= 2.0;", "")
+	expectCheck(t, "double d = 1.0; d %= 2.0;", diagnostics.CodeInvalidOperands)
+	expectCheck(t, "int i = 0; i += 1.5;", diagnostics.CodeInvalidOperands)
+	expectCheck(t, "string s = \"a\"; s++;", diagnostics.CodeInvalidOperands)
+	expectCheck(t, "n++;", diagnostics.CodeUndefinedVariable)
+
+	// Для double ++ не подходит: ошибка говорит об операторе, а не о типе единицы
+	l := lexer.NewLexer("double d = 1.5; d++;")
+	_, err := semantics.NewTypeChecker().Check(parser.NewParser(*l.LexerAnalysis()).ParseCode())
+	d, ok := err.(diagnostics.Diagnostic)
+	if !ok || d.Code != diagnostics.CodeInvalidOperands || !strings.Contains(d.Message, "++ определена только для int") {
+		t.Errorf("d++ для double: %v", err)
+	}
+	expectCheck(t, "double d = 1.5; d--;", diagnostics.CodeInvalidOperands)
+	expectCheck(t, "double d = 1.5; d += 1.0;", "")
+}
+
+func TestIntegerOperatorChecks(t *testing.T) {
+	expectCheck(t, "int x = 7 % 3 & 1 | 2 ^ 4 << 1 >> 1;", "")
+	expectCheck(t, "int x = 2 ** 10; double y = 2.0 ** 3; double z = 2.0 ** 0.5;", "")
+	expectCheck(t, "double x = 1.5 & 1.0;", diagnostics.CodeInvalidOperands)
+	expectCheck(t, "int x = 1 << 1.0;", diagnostics.CodeInvalidOperands)
+	expectCheck(t, "boolean x = true | false;", diagnostics.CodeInvalidOperands)
+	expectCheck(t, "double x = 1.0 % 2.0;", diagnostics.CodeInvalidOperands)
+	expectCheck(t, "double x = 2 ** 0.5;", diagnostics.CodeInvalidOperands)
+}
+
+// TestUnaryChecks — минус только для чисел, отрицание только для boolean
+func TestUnaryChecks(t *testing.T) {
+	expectCheck(t, "int x = -1; double y = -1.5; boolean z = not true; boolean w = !z;", "")
+	expectCheck(t, "int x = - -1;", "")
+	expectCheck(t, "boolean x = -true;", diagnostics.CodeInvalidOperands)
+	expectCheck(t, `string s = -"a";`, diagnostics.CodeInvalidOperands)
+	expectCheck(t, "boolean x = not 1;", diagnostics.CodeInvalidOperands)
+	expectCheck(t, "boolean x = !1.5;", diagnostics.CodeInvalidOperands)
+	expectCheck(t, "int x = -(1 < 2);", diagnostics.CodeInvalidOperands)
+}
+
+// TestLogicalOperatorChecks — сравнения требуют одинаковых типов, порядок
+// не определён для boolean, логические операции принимают только boolean
+func TestLogicalOperatorChecks(t *testing.T) {
+	expectCheck(t, "boolean x = 1 <= 2 && 2.0 >= 1.5 || !(1 != 2);", "")
+	expectCheck(t, `boolean x = "a" == "b"; boolean y = "a" < "b"; boolean z = true != false;`, "")
+	expectCheck(t, "boolean x = 1 less 2 and not false or 1.5 more-or-equal 2.5;", "")
+	expectCheck(t, "boolean x = 1 < 1.5;", diagnostics.CodeInvalidOperands)
+	expectCheck(t, `boolean x = "a" == 1;`, diagnostics.CodeInvalidOperands)
+	expectCheck(t, "boolean x = true <= false;", diagnostics.CodeInvalidOperands)
+	expectCheck(t, "boolean x = 1 && 2;", diagnostics.CodeInvalidOperands)
+	expectCheck(t, "boolean x = true or 1;", diagnostics.CodeInvalidOperands)
+	expectCheck(t, "int x = 1 == 1;", diagnostics.CodeTypeMismatch)
+}
+
+// TestShowChecks — show выводит любое выражение, у которого есть значение
+func TestShowChecks(t *testing.T) {
+	expectCheck(t, `func f() int { return 1; }; show f() + 1; show 1.5 * 2.0; show "a"; show 1 < 2;`, "")
+	expectCheck(t, "func f() { }; show f();", diagnostics.CodeTypeMismatch)
+	expectCheck(t, "show exit(1);", diagnostics.CodeTypeMismatch)
+}
+
+func TestReturnChecks(t *testing.T) {
+	expectCheck(t, "func add(int a, int b) int { return a + b; }; int x = add(1, 2);", "")
+	expectCheck(t, "func fact(int n) int { if n <= 1 { return 1; }; return n * fact(n - 1); };", "")
+	expectCheck(t, "func sign(int n) int { if n < 0 { return -1; } else { return 1; }; };", "")
+	expectCheck(t, "func hello(string s) { show s; return; };", "")
+
+	expectCheck(t, "func f(int a) int { if a > 0 { return 1; }; };", diagnostics.CodeMissingReturn)
+	expectCheck(t, "func f() int { while true { return 1; }; };", diagnostics.CodeMissingReturn)
+	expectCheck(t, "func f() int { return 1.5; };", diagnostics.CodeReturnType)
+	expectCheck(t, "func f() { return 1; };", diagnostics.CodeReturnType)
+	expectCheck(t, "func f() int { return; };", diagnostics.CodeReturnType)
+	expectCheck(t, "return 1;", diagnostics.CodeReturnOutside)
+	expectCheck(t, "func f(int a, int a) { };", diagnostics.CodeRedeclaration)
+	expectCheck(t, "func f() int { return 1; }; boolean b = f();", diagnostics.CodeTypeMismatch)
+	expectCheck(t, "func f(int a) { }; f(1.5);", diagnostics.CodeArgumentType)
+}
+
+func TestNestedFunctionChecks(t *testing.T) {
+	expectCheck(t, "func f() { func g() { }; };", diagnostics.CodeNestedFunction)
+	expectCheck(t, "if true { func g() { }; };", diagnostics.CodeNestedFunction)
+	expectCheck(t, "while false { func g() { }; };", diagnostics.CodeNestedFunction)
+}
+
+func TestScopeChecks(t *testing.T) {
+	// Вложенный блок может скрыть внешнюю переменную, а его переменные не видны снаружи
+	expectCheck(t, "int x = 1; if true { double x = 2.0; x = 3.0; }; x = 4;", "")
+	expectCheck(t, "if true { int y = 1; }; y = 2;", diagnostics.CodeUndefinedVariable)
+	expectCheck(t, "while false { int t = 1; }; while false { int t = 2; };", "")
+	expectCheck(t, "if true { int a = 1; int a = 2; };", diagnostics.CodeRedeclaration)
+
+	// Функция видит глобальные переменные, но не локальные переменные вызывающего блока
+	expectCheck(t, "int total = 0; func add(int n) { total = total + n; };", "")
+	expectCheck(t, "if true { int local = 1; func f() { }; };", diagnostics.CodeNestedFunction)
+	expectCheck(t, "func f(int a) { int a = 2; };", diagnostics.CodeRedeclaration)
+	expectCheck(t, "func f(int a) { if true { int a = 2; }; };", "")
+
+	// Функции и переменные живут в одном пространстве имён
+	expectCheck(t, "func f() { }; int f = 1;", diagnostics.CodeRedeclaration)
+	expectCheck(t, "func f() { }; f = 1;", diagnostics.CodeTypeMismatch)
+	expectCheck(t, "func f() int { return 1; }; int x = f + 1;", diagnostics.CodeTypeMismatch)
+	expectCheck(t, "int g = 1; g();", diagnostics.CodeUndefinedFunction)
+}
+
+// TestSymbolTable — поиск идёт через родительские области, объявление скрывает внешнее
+func TestSymbolTable(t *testing.T) {
+	global := semantics.NewSymbolTable(nil)
+	global.Declare(&semantics.Symbol{Name: "x", Kind: semantics.SymbolVariable, Type: "int"})
+	inner := semantics.NewSymbolTable(global)
+	if sym, ok := inner.Lookup("x"); !ok || sym.Type != "int" {
+		t.Fatalf("x не найдена через родительскую область")
+	}
+	if _, ok := inner.LookupLocal("x"); ok {
+		t.Errorf("LookupLocal нашёл x во внешней области")
+	}
+	if _, ok := inner.Declare(&semantics.Symbol{Name: "x", Kind: semantics.SymbolVariable, Type: "double"}); !ok {
+		t.Fatalf("не удалось скрыть внешнюю x")
+	}
+	if sym, _ := inner.Lookup("x"); sym.Type != "double" {
+		t.Errorf("внутренняя x не скрыла внешнюю")
+	}
+	if existing, ok := inner.Declare(&semantics.Symbol{Name: "x"}); ok || existing.Type != "double" {
+		t.Errorf("повторное объявление в той же области должно вернуть прежний символ")
+	}
+	if inner.Global() != global || !global.IsGlobal() || inner.IsGlobal() {
+		t.Errorf("неверная глобальная область")
+	}
+}
+
+// TestForwardReferences — функции верхнего уровня можно вызывать до объявления
+func TestForwardReferences(t *testing.T) {
+	expectCheck(t, "int x = twice(2); func twice(int v) int { return v * 2; };", "")
+	expectCheck(t, "func isEven(int k) boolean { if k == 0 { return true; }; return isOdd(k - 1); }; "+
+		"func isOdd(int k) boolean { if k == 0 { return false; }; return isEven(k - 1); };", "")
+	expectCheck(t, "boolean b = later(); func later() int { return 1; };", diagnostics.CodeTypeMismatch)
+	expectCheck(t, "later(1, 2); func later(int a) { };", diagnostics.CodeArgumentCount)
+	expectCheck(t, "func f() { }; func f() { };", diagnostics.CodeRedeclaration)
+	expectCheck(t, "int f = 1; func f() { };", diagnostics.CodeRedeclaration)
+	// Переменные не поднимаются: функция видит только глобальные, объявленные выше неё
+	expectCheck(t, "func f() int { return late; }; int late = 1;", diagnostics.CodeUndefinedVariable)
+}
+
+// TestInitializationOrder — вызов с верхнего уровня не может выполниться раньше
+// объявления глобальной переменной, к которой обращается функция, в том числе
+// через другие функции
+func TestInitializationOrder(t *testing.T) {
+	expectCheck(t, "int x = f(); show x; int g = 5; func f() int { return g; };", diagnostics.CodeUninitializedGlobal)
+	expectCheck(t, "show f(); int g = 5; func f() int { return h(); }; func h() int { g = g + 1; return g; };", diagnostics.CodeUninitializedGlobal)
+	expectCheck(t, "if true { f(); }; int g = 5; func f() { show g; };", diagnostics.CodeUninitializedGlobal)
+	// Инициализатор сам ещё не выполнен, когда вызывается функция
+	expectCheck(t, "int g = f(); func f() int { return g; };", diagnostics.CodeUninitializedGlobal)
+
+	expectCheck(t, "int g = 5; int x = f(); func f() int { return g; };", "")
+	expectCheck(t, "int g = 5; func f() int { return g; }; int x = f();", "")
+	expectCheck(t, "int x = f(3); func f(int n) int { if n == 0 { return 0; }; return f(n - 1); };", "")
+	// Локальная переменная с тем же именем не связана с глобальной
+	expectCheck(t, "int x = f(); int g = 5; func f() int { int g = 1; return g; };", "")
+
+	// Ошибка указывает на вызов, обращение внутри функции и объявление переменной
+	l := lexer.NewLexer("int x = f(); int g = 5; func f() int { return g; };")
+	_, err := semantics.NewTypeChecker().Check(parser.NewParser(*l.LexerAnalysis()).ParseCode())
+	if d, ok := err.(diagnostics.Diagnostic); !ok || len(d.Related) != 2 {
+		t.Errorf("ожидались ссылки на обращение и объявление: %#v", err)
+	}
+}
+
+func TestBuiltinChecks(t *testing.T) {
+	expectCheck(t, "exit(0);", "")
+	expectCheck(t, "func f(int code) { exit(code + 1); }; f(2);", "")
+	expectCheck(t, "exit();", diagnostics.CodeArgumentCount)
+	expectCheck(t, "exit(1.5);", diagnostics.CodeArgumentType)
+	expectCheck(t, "int x = exit(1);", diagnostics.CodeTypeMismatch)
+	expectCheck(t, "func exit(int code) { };", diagnostics.CodeRedeclaration)
+	expectCheck(t, "int exit = 1;", diagnostics.CodeRedeclaration)
+}